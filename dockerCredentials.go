@@ -0,0 +1,190 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Registry credentials of the Docker CLI configuration.
+//
+// The credentials are read the way the docker CLI reads them: from the credHelpers entry of the registry, from the credsStore,
+// or from the auths entry of ~/.docker/config.json (or $DOCKER_CONFIG/config.json). Credentials given on the command line are
+// passed to docker login on stdin so they never appear in the process list.
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const dockerHubServerAddress = "https://index.docker.io/v1/"
+const credentialHelperTokenUsername = "<token>"
+
+const credentialsSourceAnonymous = "anonymous"
+const credentialsSourceCommandLine = "command line"
+const credentialsSourcePrompt = "prompt"
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the parts of the Docker CLI configuration file holding the registry credentials
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type dockerConfigFileStruct struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredHelpers map[string]string `json:"credHelpers"`
+	CredsStore  string            `json:"credsStore"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the credentials used for a registry. An identity token replaces the password when it is set.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type registryCredentialsStruct struct {
+	Username      string
+	Password      string
+	IdentityToken string
+	Source        string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the path of the Docker CLI configuration file
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerConfigPath() string {
+	if configDirectory := os.Getenv("DOCKER_CONFIG"); configDirectory != "" {
+		return filepath.Join(configDirectory, "config.json")
+	}
+
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDirectory, ".docker", "config.json")
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the server address the Docker CLI stores the credentials of a registry under
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerConfigServerAddress(registry string) string {
+	if registry == dockerHubRegistry {
+		return dockerHubServerAddress
+	}
+	return registry
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gets the credentials of a registry from a docker-credential-<helper> program
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getCredentialHelperCredentials(helper string, serverAddress string) (registryCredentialsStruct, error) {
+	var credentials registryCredentialsStruct
+	var response struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	var stdout, stderr bytes.Buffer
+
+	command := exec.Command("docker-credential-"+helper, "get")
+	command.Stdin = strings.NewReader(serverAddress)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(strings.ToLower(output), "credentials not found") {
+			return credentials, nil
+		}
+		return credentials, errors.New("the credential helper docker-credential-" + helper + " failed, " + err.Error() + " " + output)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return credentials, errors.New("the response of the credential helper docker-credential-" + helper + " is not valid JSON, " + err.Error())
+	}
+
+	credentials.Source = "docker-credential-" + helper
+	if response.Username == credentialHelperTokenUsername {
+		credentials.IdentityToken = response.Secret
+	} else {
+		credentials.Username = response.Username
+		credentials.Password = response.Secret
+	}
+	return credentials, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gets the credentials of a registry from the Docker CLI configuration. Returns empty credentials if none are configured.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerConfigCredentials(registry string) (registryCredentialsStruct, error) {
+	var credentials registryCredentialsStruct
+	var config dockerConfigFileStruct
+
+	configPath := getDockerConfigPath()
+	content, err := ioutil.ReadFile(configPath)
+	if os.IsNotExist(err) || configPath == "" {
+		return credentials, nil
+	}
+	if err != nil {
+		return credentials, err
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return credentials, errors.New("the Docker configuration file " + configPath + " is not valid JSON, " + err.Error())
+	}
+
+	serverAddress := getDockerConfigServerAddress(registry)
+	if helper := config.CredHelpers[serverAddress]; helper != "" {
+		return getCredentialHelperCredentials(helper, serverAddress)
+	}
+	if config.CredsStore != "" {
+		return getCredentialHelperCredentials(config.CredsStore, serverAddress)
+	}
+
+	for _, address := range []string{serverAddress, "https://" + serverAddress, "http://" + serverAddress} {
+		auth, found := config.Auths[address]
+		if !found {
+			continue
+		}
+
+		credentials = registryCredentialsStruct{Username: auth.Username, Password: auth.Password, IdentityToken: auth.IdentityToken, Source: configPath}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return credentials, errors.New("the auth of " + address + " in " + configPath + " is not valid base64, " + err.Error())
+			}
+			userPassword := strings.SplitN(string(decoded), ":", 2)
+			if len(userPassword) != 2 {
+				return credentials, errors.New("the auth of " + address + " in " + configPath + " is not in the user:password form")
+			}
+			credentials.Username, credentials.Password = userPassword[0], userPassword[1]
+		}
+		break
+	}
+
+	return credentials, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether stdin is a terminal the user can be prompted on
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Logs in to a registry so docker plugin install can pull the plugin. The password is passed on stdin.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func dockerLogin(registry string, credentials registryCredentialsStruct) (string, error) {
+	arguments := []string{"login", "--username", credentials.Username, "--password-stdin"}
+	if registry != dockerHubRegistry {
+		arguments = append(arguments, registry)
+	}
+
+	command := exec.Command("docker", arguments...)
+	command.Stdin = strings.NewReader(credentials.Password)
+	output, err := command.CombinedOutput()
+
+	return strings.TrimSpace(string(output)), err
+}
@@ -0,0 +1,148 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Parser of Docker plugin references: [registry-host[:port]/]path[:tag][@digest]
+//
+// The grammar follows https://github.com/distribution/reference. A reference without a registry host refers to Docker Hub,
+// a Docker Hub reference without a namespace refers to library/, and a reference without a tag or digest refers to latest.
+//
+
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const dockerHubRegistry = "docker.io"
+const dockerDefaultTag = "latest"
+
+var referencePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*$`)
+var referenceRegistryRegexp = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*|\[[a-fA-F0-9:]+\])(?::[0-9]+)?$`)
+var referenceTagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+var referenceDigestRegexp = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]{32,}$`)
+var referenceSHA256Regexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the components of a plugin reference
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type dockerReferenceStruct struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Parses a plugin reference into its registry, repository, tag and digest
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func parseDockerReference(name string) (dockerReferenceStruct, error) {
+	var reference dockerReferenceStruct
+
+	if name == "" {
+		return reference, errors.New("the Docker Networking Plugin reference is empty")
+	}
+
+	if index := strings.Index(name, "@"); index >= 0 {
+		name, reference.Digest = name[:index], name[index+1:]
+		if !referenceDigestRegexp.MatchString(reference.Digest) {
+			return reference, errors.New("the digest " + reference.Digest + " of the Docker Networking Plugin reference is not valid")
+		}
+		if strings.HasPrefix(reference.Digest, "sha256:") && !referenceSHA256Regexp.MatchString(reference.Digest) {
+			return reference, errors.New("the sha256 digest " + reference.Digest + " of the Docker Networking Plugin reference is not 64 lowercase hex characters")
+		}
+	}
+
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name, reference.Tag = name[:index], name[index+1:]
+		if !referenceTagRegexp.MatchString(reference.Tag) {
+			return reference, errors.New("the tag " + reference.Tag + " of the Docker Networking Plugin reference is not valid")
+		}
+	}
+
+	reference.Registry = dockerHubRegistry
+	if index := strings.Index(name, "/"); index >= 0 {
+		host := name[:index]
+		if strings.ContainsAny(host, ".:") || host == "localhost" || strings.ToLower(host) != host {
+			if !referenceRegistryRegexp.MatchString(host) {
+				return reference, errors.New("the registry host " + host + " of the Docker Networking Plugin reference is not valid")
+			}
+			reference.Registry, name = host, name[index+1:]
+		}
+	}
+	if reference.Registry == "index.docker.io" || reference.Registry == "registry-1.docker.io" {
+		reference.Registry = dockerHubRegistry
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if !referencePathComponentRegexp.MatchString(component) {
+			return reference, errors.New("the repository " + name + " of the Docker Networking Plugin reference is not valid, it must be lowercase and made of path components separated by /")
+		}
+	}
+	if reference.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	reference.Repository = name
+
+	if reference.Tag == "" && reference.Digest == "" {
+		reference.Tag = dockerDefaultTag
+	}
+
+	return reference, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the repository in the short form used by the docker CLI, without the Docker Hub registry and library/ namespace
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getFamiliarRepository(reference dockerReferenceStruct) string {
+	if reference.Registry != dockerHubRegistry {
+		return reference.Registry + "/" + reference.Repository
+	}
+	return strings.TrimPrefix(reference.Repository, "library/")
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the reference the plugin is pulled with. A digest takes precedence over the tag.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatDockerReference(reference dockerReferenceStruct) string {
+	if reference.Digest != "" {
+		return getFamiliarRepository(reference) + "@" + reference.Digest
+	}
+	return getFamiliarRepository(reference) + ":" + reference.Tag
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the name the plugin is installed under. A plugin pulled by digest is installed under its tag, or latest if it has none.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginLocalName(reference dockerReferenceStruct) string {
+	if reference.Tag == "" {
+		return getFamiliarRepository(reference) + ":" + dockerDefaultTag
+	}
+	return getFamiliarRepository(reference) + ":" + reference.Tag
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the registry manifest reference of the plugin. A digest takes precedence over the tag.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getManifestReference(reference dockerReferenceStruct) string {
+	if reference.Digest != "" {
+		return reference.Digest
+	}
+	return reference.Tag
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the file name prefix of the reports written for the plugin
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getReportFileNamePrefix() string {
+	version := inspectionData.DockerNetworkingPluginTag
+	if inspectionData.DockerNetworkingPluginReferenceDigest != "" {
+		digest := strings.Replace(inspectionData.DockerNetworkingPluginReferenceDigest, ":", "_", 1)
+		if version == "" {
+			version = truncateString(digest, 19)
+		} else {
+			version += "-" + truncateString(digest, 19)
+		}
+	}
+	return strings.Replace(inspectionData.DockerNetworkingPluginRepo, `/`, `-`, -1) + "-" + version
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDockerReference(t *testing.T) {
+	var digest = "sha256:" + strings.Repeat("0123456789abcdef", 4)
+	var testCases = []struct {
+		name     string
+		expected dockerReferenceStruct
+	}{
+		{"foo", dockerReferenceStruct{Registry: "docker.io", Repository: "library/foo", Tag: "latest"}},
+		{"foo/bar:1", dockerReferenceStruct{Registry: "docker.io", Repository: "foo/bar", Tag: "1"}},
+		{"docker.io/foo/bar", dockerReferenceStruct{Registry: "docker.io", Repository: "foo/bar", Tag: "latest"}},
+		{"index.docker.io/foo", dockerReferenceStruct{Registry: "docker.io", Repository: "library/foo", Tag: "latest"}},
+		{"registry.local:5000/foo/bar", dockerReferenceStruct{Registry: "registry.local:5000", Repository: "foo/bar", Tag: "latest"}},
+		{"registry.local:5000/foo/bar:1.0", dockerReferenceStruct{Registry: "registry.local:5000", Repository: "foo/bar", Tag: "1.0"}},
+		{"localhost/foo", dockerReferenceStruct{Registry: "localhost", Repository: "foo", Tag: "latest"}},
+		{"[::1]:5000/x:y", dockerReferenceStruct{Registry: "[::1]:5000", Repository: "x", Tag: "y"}},
+		{"foo/bar@" + digest, dockerReferenceStruct{Registry: "docker.io", Repository: "foo/bar", Digest: digest}},
+		{"foo/bar:1@" + digest, dockerReferenceStruct{Registry: "docker.io", Repository: "foo/bar", Tag: "1", Digest: digest}},
+		{"registry.local:5000/foo/bar@" + digest, dockerReferenceStruct{Registry: "registry.local:5000", Repository: "foo/bar", Digest: digest}},
+	}
+
+	for _, testCase := range testCases {
+		reference, err := parseDockerReference(testCase.name)
+		if err != nil {
+			t.Errorf("parseDockerReference(%q) returned the error: %s", testCase.name, err.Error())
+		} else if reference != testCase.expected {
+			t.Errorf("parseDockerReference(%q) = %+v, expected %+v", testCase.name, reference, testCase.expected)
+		}
+	}
+}
+
+func TestParseDockerReferenceErrors(t *testing.T) {
+	var invalidNames = []string{
+		"",
+		"foo/Bar",
+		"foo/bar:",
+		"foo/bar:-1",
+		"foo/bar@sha256:0123",
+		"foo/bar@sha256:" + strings.Repeat("0123456789ABCDEF", 4),
+		"registry_local:5000/foo",
+		"foo//bar",
+	}
+
+	for _, name := range invalidNames {
+		if reference, err := parseDockerReference(name); err == nil {
+			t.Errorf("parseDockerReference(%q) = %+v, expected an error", name, reference)
+		}
+	}
+}
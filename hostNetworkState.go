@@ -0,0 +1,153 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Snapshots of the host networking state taken around the Docker Networking Plugin install, test and removal steps.
+//
+// The snapshots are compared to detect links, addresses, routes, firewall rules, network namespaces and bridge devices
+// the plugin leaves behind on the host.
+//
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a category of host networking state and the command used to list it
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type hostNetworkStateCommandStruct struct {
+	Category string
+	Command  string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This type holds a snapshot of the host networking state. It maps a category to the entries listed for that category.
+// Categories whose command could not be run on the host are not present in the snapshot.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type hostNetworkStateStruct map[string][]string
+
+var hostNetworkStateCommands = []hostNetworkStateCommandStruct{
+	{"link", `ip -o link show | awk -F': ' '{ print $2 }'`},
+	{"address", `ip -o addr show | awk '{ print $2, $4 }'`},
+	{"route", `ip route show table all && ip -6 route show table all`},
+	{"iptables rule", `iptables-save`},
+	{"ip6tables rule", `ip6tables-save`},
+	{"nftables rule", `nft list ruleset`},
+	{"network namespace", `ip netns list && (ls /var/run/docker/netns 2>/dev/null || true)`},
+	{"bridge device", `ip -o link show type bridge | awk -F': ' '{ print $2 }'`},
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Volatile parts of the listed entries (packet counters, timestamps, expiry timers) which are removed before comparing snapshots
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var hostNetworkStateVolatileRegexp = regexp.MustCompile(`\[\d+:\d+\]|expires \d+sec|\s+$`)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns true if the host networking state can be checked on this operating system
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func hostNetworkStateSupported() bool {
+	return runtime.GOOS == "linux"
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Takes a snapshot of the host networking state
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func snapshotHostNetworkState() hostNetworkStateStruct {
+	var hostNetworkState = hostNetworkStateStruct{}
+
+	if !hostNetworkStateSupported() {
+		return hostNetworkState
+	}
+
+	for _, stateCommand := range hostNetworkStateCommands {
+		output, err := runCommand(stateCommand.Command)
+		if err != nil {
+			continue
+		}
+
+		var entries = []string{}
+		for _, line := range strings.Split(output, "\n") {
+			line = hostNetworkStateVolatileRegexp.ReplaceAllString(line, "")
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			entries = append(entries, line)
+		}
+		hostNetworkState[stateCommand.Category] = entries
+	}
+
+	return hostNetworkState
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the entries which are in the first list but not in the second list
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func subtractHostNetworkStateEntries(entries []string, otherEntries []string) []string {
+	var otherEntriesCount = map[string]int{}
+	var difference []string
+
+	for _, entry := range otherEntries {
+		otherEntriesCount[entry]++
+	}
+
+	for _, entry := range entries {
+		if otherEntriesCount[entry] > 0 {
+			otherEntriesCount[entry]--
+			continue
+		}
+		difference = append(difference, entry)
+	}
+
+	sort.Strings(difference)
+	return difference
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 snapshots of the host networking state and reports what was left behind or removed between them.
+// Entries left behind are reported as errors if leftoversAreErrors is true, otherwise as warnings. Returns true if nothing was left behind.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareHostNetworkState(before hostNetworkStateStruct, after hostNetworkStateStruct, stage string, leftoversAreErrors bool) bool {
+	var clean = true
+
+	for _, stateCommand := range hostNetworkStateCommands {
+		beforeEntries, beforeFound := before[stateCommand.Category]
+		afterEntries, afterFound := after[stateCommand.Category]
+		if !beforeFound || !afterFound {
+			continue
+		}
+
+		leftovers := subtractHostNetworkStateEntries(afterEntries, beforeEntries)
+		if len(leftovers) > 0 {
+			clean = false
+			message := fmt.Sprintf("%d host %s(s) were left behind after %s: %s", len(leftovers), stateCommand.Category, stage, strings.Join(leftovers, ", "))
+			if leftoversAreErrors {
+				printError(message)
+			} else {
+				printWarning(message)
+			}
+		}
+
+		removed := subtractHostNetworkStateEntries(beforeEntries, afterEntries)
+		if len(removed) > 0 {
+			printWarning(fmt.Sprintf("%d host %s(s) were removed after %s: %s", len(removed), stateCommand.Category, stage, strings.Join(removed, ", ")))
+		}
+	}
+
+	return clean
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Prints the changes made to the host networking state between 2 snapshots. Used for changes which are expected, such as installing the plugin.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func printHostNetworkStateChanges(before hostNetworkStateStruct, after hostNetworkStateStruct, stage string) {
+	for _, stateCommand := range hostNetworkStateCommands {
+		added := subtractHostNetworkStateEntries(after[stateCommand.Category], before[stateCommand.Category])
+		if len(added) > 0 {
+			printMessage(fmt.Sprintf("%d host %s(s) were added by %s: %s", len(added), stateCommand.Category, stage, strings.Join(added, ", ")))
+		}
+	}
+}
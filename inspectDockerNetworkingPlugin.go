@@ -7,11 +7,39 @@
 //   Options:
 //             [--docker-user]					Docker ID
 //             [--docker-password]					Docker ID password
-//			[--docker-registry-auth-endpoint]       Defaults to https://auth.docker.io
 //             [--docker-registry-api-endpoint]        Defaults to https://registry-1.docker.io
+//             [--anonymous]                           Access the registry anonymously instead of using credentials
+//             [--registry-ca-cert file]               CA certificate trusted for the registry
+//             [--registry-client-cert file]           Client certificate presented to the registry
+//             [--registry-client-key file]            Key of the client certificate presented to the registry
+//             [--registry-insecure]                   Do not verify the certificate of the registry and fall back to plain HTTP
+//             [--plugin-dir directory]                Inspect an unpublished plugin from a directory containing config.json and rootfs/
+//             [--plugin-archive path]                 Inspect a plugin offline from an OCI image layout directory or oci-archive tarball
 //			[--test-script scriptname]              Specify an optional script to test the Docker Networking Plugin. The script gets passed 1 parameter - the Docker Networking Plugin name.
 //             [--json]  						Generate Output in JSON to stdout
 //			[--html]  						Generate Output in HTML
+//             [--ipam-subnet]                         Subnet used for the IPAM test. Defaults to 10.199.0.0/16
+//             [--ipam-gateway]                        Gateway used for the IPAM test. Defaults to 10.199.0.1
+//             [--ipam-ip-range]                       IP range used for the IPAM test. Defaults to 10.199.1.0/24
+//             [--ipam-aux-address]                    Auxiliary addresses used for the IPAM test. Defaults to reserved=10.199.0.2
+//             [--ipam-static-ip]                      Static container IP address used for the IPAM test. Defaults to 10.199.1.10
+//             [--ipv6]                                Test the IPv6 and dual-stack support of the plugin
+//             [--ipv6-subnet]                         IPv6 subnet used for the IPv6 test. Defaults to fd00:dead:beef::/64
+//             [--ipv6-gateway]                        IPv6 gateway used for the IPv6 test. Defaults to fd00:dead:beef::1
+//             [--driver-opt key=value]                Driver specific option passed to the plugin when creating the test networks. Can be repeated.
+//             [--network-label key=value]             Label set on the test networks. Can be repeated.
+//             [--soak-iterations n]                   Run the Docker network create/delete soak test n times
+//             [--soak-duration d]                     Run the Docker network create/delete soak test for the duration d (for example 10m)
+//             [--protocol-test]                       Test the remote network driver API directly over the plugin's socket
+//             [--plugin-socket path]                  Path of the plugin's socket used by the protocol test
+//             [--record-session file]                 Save the plugin API session recorded during the tests to a file
+//             [--replay-session file]                 Replay a saved plugin API session and report responses which differ
+//             [--replay-ignore-field field]           Response field not compared when replaying a session. Can be repeated.
+//             [--fuzz]                                Send malformed payloads to the remote network driver API over the plugin's socket
+//             [--advisory-db path]                    OSV advisory database used to scan the SBOM of the plugin for vulnerabilities
+//             [--vulnerability-error-severity s]      Vulnerabilities of severity s or higher are reported as errors. Defaults to high
+//             [--vulnerability-warning-severity s]    Vulnerabilities of severity s or higher are reported as warnings. Defaults to unknown
+//             [--trace]                               Record the plugin API traffic during the tests and include it in the report
 //             [-v]      						Verbose output
 //             [-h]      						Help
 //
@@ -29,8 +57,10 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -56,9 +86,19 @@ var dockerPluginConfigurationBlob = dockerAPI.DockerPluginConfigurationBlob{}
 
 var inspectionData = inspectionStruct{}
 
+var testNetworkOptions = dockerNetworkOptionsStruct{}
+var ipamTestOptions = dockerNetworkOptionsStruct{}
+var ipamTestStaticIPAddress string
+var ipv6Test = false
+var ipv6TestOptions = dockerNetworkOptionsStruct{}
+
 const termReportLineLength = 194
 const termImageInformationLineLength = 164
 const testNetworkName = "test_network"
+const testContainerImage = "busybox:latest"
+const testContainerTCPPort = "8080"
+
+var testContainerNames = []string{"test_container_1", "test_container_2"}
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // This structure defines the structure to hold the Inspection Data and Results.
@@ -75,6 +115,10 @@ type inspectionStruct struct {
 	DockerNetworkingPlugin                     string
 	DockerNetworkingPluginRepo                 string
 	DockerNetworkingPluginTag                  string
+	DockerNetworkingPluginRegistry             string
+	DockerNetworkingPluginReference            string
+	DockerNetworkingPluginReferenceDigest      string
+	DockerNetworkingPluginSource               string
 	Description                                string
 	InterfaceSocket                            string
 	InterfaceSocketTypes                       string
@@ -87,10 +131,59 @@ type inspectionStruct struct {
 	User                                       string
 	IpcHost                                    string
 	PidHost                                    string
+	Privileges                                 []string
+	PluginConfiguration                        []pluginConfigInformationStruct
 	HTMLMessages                               []template.HTML
 	TestResults                                []template.HTML
 	HTMLReportFile                             string
-	VulnerabilitiesScanURL                     string
+	SoakResults                                *soakResultsStruct
+	Trace                                      []pluginAPIExchangeStruct
+	RootfsAnalysis                             *rootfsAnalysisStruct
+	SBOM                                       *sbomSummaryStruct
+	Vulnerabilities                            *vulnerabilityReportStruct
+	Secrets                                    []secretFindingStruct
+	Platforms                                  []pluginPlatformStruct
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the optional settings used when creating the Docker test network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type dockerNetworkOptionsStruct struct {
+	Driver        string
+	IPAMDriver    string
+	Subnet        string
+	Gateway       string
+	IPRange       string
+	AuxAddresses  []string
+	EnableIPv6    bool
+	IPv6Subnet    string
+	IPv6Gateway   string
+	DriverOptions []string
+	Labels        []string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This type defines a command line option which can be specified multiple times
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type stringSliceFlag []string
+
+func (flagValues *stringSliceFlag) String() string {
+	return strings.Join(*flagValues, ",")
+}
+
+func (flagValues *stringSliceFlag) Set(value string) error {
+	*flagValues = append(*flagValues, value)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines an entry of the IPAM configuration returned by docker network inspect
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type dockerNetworkIPAMConfigStruct struct {
+	Subnet             string            `json:"Subnet"`
+	IPRange            string            `json:"IPRange"`
+	Gateway            string            `json:"Gateway"`
+	AuxiliaryAddresses map[string]string `json:"AuxiliaryAddresses"`
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -105,27 +198,47 @@ type jsonResultsStruct struct {
 // This structure defines the JSON Output
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 type jsonOutputStruct struct {
-	Date                                       string `json:"Date"`
-	SystemOperatingSystem                      string `json:"SystemOperatingSystem"`
-	SystemArchitecture                         string `json:"SystemArchitecture"`
-	SystemDockerVersion                        string `json:"SystemDockerVersion"`
-	DockerNetworkingPlugin                     string `json:"DockerLogginPlugin"`
-	Description                                string `json:"Description"`
-	Documentation                              string `json:"Documentation"`
-	DockerNetworkingPluginDigest               string `json:"DockerNetworkingPluginDigest"`
-	DockerNetworkingPluginBaseLayerImageDigest string `json:"BaseLayerImageDigest"`
-	DockerNetworkingPluginDockerVersion        string `json:"DockerVersion,omitempty"`
-	EntryPoint                                 string `json:"Entrypoint"`
-	InterfaceSocket                            string `json:"InterfaceSocket"`
-	InterfaceSocketTypes                       string `json:"InterfaceSocketTypes"`
-	WorkDir                                    string `json:"WorkDir"`
-	User                                       string `json:"User"`
-	IpcHost                                    bool   `json:"IpcHost"`
-	PidHost                                    bool   `json:"PidHost"`
-	Errors                                     int    `json:"Errors"`
-	Warnings                                   int    `json:"Warnings"`
-	HTMLReportFile                             string `json:"HTMLReportFile"`
-	VulnerabilitiesScanURL                     string
+	Date                                       string                     `json:"Date"`
+	SystemOperatingSystem                      string                     `json:"SystemOperatingSystem"`
+	SystemArchitecture                         string                     `json:"SystemArchitecture"`
+	SystemDockerVersion                        string                     `json:"SystemDockerVersion"`
+	DockerNetworkingPlugin                     string                     `json:"DockerLogginPlugin"`
+	DockerNetworkingPluginRegistry             string                     `json:"Registry"`
+	DockerNetworkingPluginRepo                 string                     `json:"Repository"`
+	DockerNetworkingPluginTag                  string                     `json:"Tag"`
+	DockerNetworkingPluginReferenceDigest      string                     `json:"ReferenceDigest,omitempty"`
+	DockerNetworkingPluginSource               string                     `json:"Source,omitempty"`
+	Description                                string                     `json:"Description"`
+	Documentation                              string                     `json:"Documentation"`
+	DockerNetworkingPluginDigest               string                     `json:"DockerNetworkingPluginDigest"`
+	DockerNetworkingPluginBaseLayerImageDigest string                     `json:"BaseLayerImageDigest"`
+	DockerNetworkingPluginDockerVersion        string                     `json:"DockerVersion,omitempty"`
+	EntryPoint                                 string                     `json:"Entrypoint"`
+	InterfaceSocket                            string                     `json:"InterfaceSocket"`
+	InterfaceSocketTypes                       string                     `json:"InterfaceSocketTypes"`
+	WorkDir                                    string                     `json:"WorkDir"`
+	User                                       string                     `json:"User"`
+	IpcHost                                    bool                       `json:"IpcHost"`
+	PidHost                                    bool                       `json:"PidHost"`
+	Privileges                                 []string                   `json:"Privileges"`
+	Env                                        []pluginConfigEnvStruct    `json:"Env"`
+	Args                                       pluginConfigArgsStruct     `json:"Args"`
+	Mounts                                     []pluginConfigMountStruct  `json:"Mounts"`
+	Capabilities                               []string                   `json:"Capabilities"`
+	Devices                                    []pluginConfigDeviceStruct `json:"Devices"`
+	PropagatedMount                            string                     `json:"PropagatedMount"`
+	NetworkType                                string                     `json:"NetworkType"`
+	RootfsDiffIDs                              []string                   `json:"RootfsDiffIDs"`
+	Errors                                     int                        `json:"Errors"`
+	Warnings                                   int                        `json:"Warnings"`
+	HTMLReportFile                             string                     `json:"HTMLReportFile"`
+	SoakResults                                *soakResultsStruct         `json:"SoakResults,omitempty"`
+	Trace                                      []pluginAPIExchangeStruct  `json:"Trace,omitempty"`
+	RootfsAnalysis                             *rootfsAnalysisStruct      `json:"RootfsAnalysis,omitempty"`
+	SBOM                                       *sbomSummaryStruct         `json:"SBOM,omitempty"`
+	Vulnerabilities                            *vulnerabilityReportStruct `json:"Vulnerabilities,omitempty"`
+	Secrets                                    []secretFindingStruct      `json:"Secrets,omitempty"`
+	Platforms                                  []pluginPlatformStruct     `json:"Platforms,omitempty"`
 	Results                                    []jsonResultsStruct
 }
 
@@ -256,6 +369,16 @@ a.ref:hover { font-size:105%; }
 	white-space:nowrap;
 	width:5%;
 }
+pre {
+	white-space:pre-wrap;
+	word-break:break-all;
+	margin-top:2px;
+	margin-bottom:2px;
+}
+summary {
+	cursor:pointer;
+	font-weight:bold;
+}
 </style>
 <title>Docker networking plugin inspection report</title>
 </head>
@@ -268,10 +391,13 @@ a.ref:hover { font-size:105%; }
 <legend>Docker Plugin information</legend>
 <table cols='2'>
 <tr><th>Docker Plugin</th><td>{{.DockerNetworkingPlugin}}</td></tr>
+<tr><th>Registry</th><td>{{.DockerNetworkingPluginRegistry}}</td></tr>
+{{if .DockerNetworkingPluginReferenceDigest}}<tr><th>Pinned digest</th><td>{{.DockerNetworkingPluginReferenceDigest}}</td></tr>{{end}}
+{{if .DockerNetworkingPluginSource}}<tr><th>Source</th><td>{{.DockerNetworkingPluginSource}}</td></tr>{{end}}
 <tr><th><a class='doc' href='https://docs.docker.com/engine/extend/config/' target='_blank'>Description</a></th><td>{{.Description}}</td></tr>
 <tr><th><a class='doc' href='https://docs.docker.com/engine/extend/config/' target='_blank'>Documentation</a></th><td>{{.Documentation}}</td></tr>
-<tr><th>Digest</th><td>{{.DockerNetworkingPluginDigest}}</td></tr>
-<tr><th>Base layer digest</th><td>{{.DockerNetworkingPluginBaseLayerImageDigest}}</td></tr>
+{{if .DockerNetworkingPluginDigest}}<tr><th>Digest</th><td>{{.DockerNetworkingPluginDigest}}</td></tr>
+<tr><th>Base layer digest</th><td>{{.DockerNetworkingPluginBaseLayerImageDigest}}</td></tr>{{end}}
 {{if .DockerNetworkingPluginDockerVersion}}<tr><th>Docker version</th><td>{{.DockerNetworkingPluginDockerVersion}}</td></tr>{{end}}
 <tr><th><a class='doc' href='https://docs.docker.com/engine/extend/config/' target='_blank'>Interface Socket</a></th><td>{{.InterfaceSocket}}</td></tr>
 <tr><th><a class='doc' href='https://docs.docker.com/engine/extend/config/' target='_blank'>Interface Socket Types</a></th><td>{{.InterfaceSocketTypes}}</td></tr>
@@ -280,6 +406,8 @@ a.ref:hover { font-size:105%; }
 <tr><th><a class='doc' href='https://docs.docker.com/engine/reference/builder/#entrypoint' target='_blank'>Entrypoint</a></th><td>{{.EntryPoint}}</td></tr>
 <tr><th><a class='doc' href='https://docs.docker.com/engine/reference/builder/#workdir' target='_blank'>WorkDir</a></th><td>{{.WorkDir}}</td></tr>
 <tr><th><a class='doc' href='https://docs.docker.com/engine/reference/builder/#user' target='_blank'>User</a></th><td>{{.User}}</td></tr>
+{{range .PluginConfiguration}}<tr><th><a class='doc' href='https://docs.docker.com/engine/extend/config/' target='_blank'>{{.Label}}</a></th><td>{{range .Values}}{{.}}<br>{{end}}</td></tr>
+{{end}}{{if .Privileges}}<tr><th><a class='doc' href='https://docs.docker.com/engine/reference/commandline/plugin_install/' target='_blank'>Privileges</a></th><td>{{range .Privileges}}{{.}}<br>{{end}}</td></tr>{{end}}
 </table>
 </fieldset>
 <br>
@@ -302,14 +430,61 @@ a.ref:hover { font-size:105%; }
 {{end}}
 </table>
 </fieldset>
-{{if .VulnerabilitiesScanURL}}
+{{if .Platforms}}
+<br>
+<br>
+<fieldset>
+<legend>Platforms ({{len .Platforms}} platforms)</legend>
+<table cols='6'>
+<tr><th>Platform</th><th>Digest</th><th>Layers</th><th>Size (bytes)</th><th>Interface Types</th><th>Status</th></tr>
+{{range .Platforms}}<tr><td>{{.Platform}}</td><td>{{.Digest}}</td><td>{{.Layers}}</td><td>{{.Size}}</td><td>{{range .InterfaceTypes}}{{.}}<br>{{end}}</td><td>{{if .Error}}{{.Error}}{{else if .Inspected}}Inspected{{else}}Passed{{end}}</td></tr>
+{{end}}
+</table>
+</fieldset>
+{{end}}
+{{with .SoakResults}}
+<br>
+<br>
+<fieldset>
+<legend>Soak Test Results ({{.Iterations}} iterations in {{.Duration}})</legend>
+<table cols='7'>
+<tr><th>Operation</th><th>Attempts</th><th>Failures</th><th>p50 (ms)</th><th>p95 (ms)</th><th>p99 (ms)</th><th>max (ms)</th></tr>
+{{range .Operations}}<tr><td>{{.Operation}}</td><td>{{.Attempts}}</td><td>{{.Failures}}</td><td>{{printf "%.0f" .P50Milliseconds}}</td><td>{{printf "%.0f" .P95Milliseconds}}</td><td>{{printf "%.0f" .P99Milliseconds}}</td><td>{{printf "%.0f" .MaxMilliseconds}}</td></tr>
+{{end}}
+</table>
+</fieldset>
+{{end}}
+{{if .Trace}}
 <br>
 <br>
 <fieldset>
-<legend>Security Scan Results</legend>
-<a class='ref' href='{{.VulnerabilitiesScanURL}}' target='_blank'>Click here to view the Security Scan Results</a>
+<legend>Plugin API Trace ({{len .Trace}} calls)</legend>
+<details>
+<summary>Click here to expand the plugin API calls captured between dockerd and the plugin</summary>
+<table cols='5'>
+<tr><th>Time</th><th>Method</th><th>Status</th><th>Time (ms)</th><th>Request / Response</th></tr>
+{{range .Trace}}<tr><td>{{.Time}}</td><td>{{.Method}}</td><td>{{if .Error}}{{.Error}}{{else}}{{.StatusCode}}{{end}}</td><td>{{printf "%.1f" .DurationMilliseconds}}</td><td><details><summary>Payloads</summary><pre>Request:  {{.Request}}
+Response: {{.Response}}</pre></details></td></tr>
+{{end}}
+</table>
+</details>
 </fieldset>
+{{end}}
+{{with .Vulnerabilities}}
 <br>
+<br>
+<fieldset>
+<legend>Vulnerability Scan Results ({{len .Findings}} vulnerabilities in {{.Packages}} packages)</legend>
+{{if .Findings}}
+<table cols='6'>
+<tr><th>Vulnerability</th><th>Severity</th><th>Package</th><th>Version</th><th>Fixed Version</th><th>Summary</th></tr>
+{{range .Findings}}<tr><td>{{.ID}}{{range .Aliases}}<br>{{.}}{{end}}</td><td>{{.Severity}}{{if .Score}} ({{.Score}}){{end}}</td><td>{{.Package}}</td><td>{{.Version}}</td><td>{{.FixedVersion}}</td><td>{{.Summary}}</td></tr>
+{{end}}
+</table>
+{{else}}
+No known vulnerabilities were found in the advisory database {{.AdvisoryDatabase}}.
+{{end}}
+</fieldset>
 {{end}}
 <br>
 <br>
@@ -339,6 +514,15 @@ func logFatalError(err error) {
 	}
 }
 
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes the unpacked plugin rootfs and the extracted plugin archive, then exits with the passed code
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func exitInspection(code int) {
+	removePluginRootfs()
+	removePluginArchive()
+	os.Exit(code)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Prints a success message
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -385,35 +569,35 @@ func printMessage(message string) {
 // Returns an HTML table row containing the error message
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func formatHTMLError(message string) template.HTML {
-	return template.HTML("<tr><td class='error_message'>Error</td><td>" + message + "</td></tr>")
+	return template.HTML("<tr><td class='error_message'>Error</td><td>" + template.HTMLEscapeString(message) + "</td></tr>")
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Returns an HTML table row containing the warning message
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func formatHTMLWarning(message string) template.HTML {
-	return template.HTML("<tr><td class='warning_message'>Warning</td><td>" + message + "</td></tr>")
+	return template.HTML("<tr><td class='warning_message'>Warning</td><td>" + template.HTMLEscapeString(message) + "</td></tr>")
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Returns an HTML table row containing the success message
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func formatHTMLSuccess(message string) template.HTML {
-	return template.HTML("<tr><td class='success_message'>Passed</td><td>" + message + "</td></tr>")
+	return template.HTML("<tr><td class='success_message'>Passed</td><td>" + template.HTMLEscapeString(message) + "</td></tr>")
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Returns an HTML table row containing the success message for verbose networking
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func formatHTMLVerboseSuccess(direction string, message string) template.HTML {
-	return template.HTML("<tr><td class='success_message'>" + direction + "</td><td>" + message + "</td></tr>")
+	return template.HTML("<tr><td class='success_message'>" + direction + "</td><td>" + template.HTMLEscapeString(message) + "</td></tr>")
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Returns an HTML table row containing the error message for verbose networking
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func formatHTMLVerboseError(direction string, message string) template.HTML {
-	return template.HTML("<tr><td class='error_message'>" + direction + "</td><td>" + message + "</td></tr>")
+	return template.HTML("<tr><td class='error_message'>" + direction + "</td><td>" + template.HTMLEscapeString(message) + "</td></tr>")
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -459,6 +643,16 @@ func runCommand(command string) (string, error) {
 	return strings.TrimSpace(string(output)), err
 }
 
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Quotes a value as a single argument of the shell runCommand runs the command with
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func shellQuote(value string) string {
+	if runtime.GOOS == "windows" {
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	}
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Returns a string which contains operating system information
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -594,8 +788,7 @@ func generateHTMLReport() {
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Open (over write) the HTML file
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	inspectionData.HTMLReportFile = `html/` + strings.Replace(inspectionData.DockerNetworkingPluginRepo, `/`, `-`, -1) + "-" + inspectionData.DockerNetworkingPluginTag +
-		"_inspection_report_" + todaysDateTime.Format("2006-01-02_03-04-05") + ".html"
+	inspectionData.HTMLReportFile = `html/` + getReportFileNamePrefix() + "_inspection_report_" + todaysDateTime.Format("2006-01-02_03-04-05") + ".html"
 	file, err := os.Create(inspectionData.HTMLReportFile)
 	if err != nil {
 		log.Fatal(err)
@@ -653,6 +846,11 @@ func generateJSONOutput() {
 	jsonOutputData.SystemArchitecture = inspectionData.SystemArchitecture
 	jsonOutputData.SystemDockerVersion = inspectionData.SystemDockerVersion
 	jsonOutputData.DockerNetworkingPlugin = inspectionData.DockerNetworkingPlugin
+	jsonOutputData.DockerNetworkingPluginRegistry = inspectionData.DockerNetworkingPluginRegistry
+	jsonOutputData.DockerNetworkingPluginRepo = inspectionData.DockerNetworkingPluginRepo
+	jsonOutputData.DockerNetworkingPluginTag = inspectionData.DockerNetworkingPluginTag
+	jsonOutputData.DockerNetworkingPluginReferenceDigest = inspectionData.DockerNetworkingPluginReferenceDigest
+	jsonOutputData.DockerNetworkingPluginSource = inspectionData.DockerNetworkingPluginSource
 	jsonOutputData.Description = inspectionData.Description
 	jsonOutputData.Documentation = inspectionData.Documentation
 	jsonOutputData.DockerNetworkingPluginDigest = inspectionData.DockerNetworkingPluginDigest
@@ -665,13 +863,28 @@ func generateJSONOutput() {
 	jsonOutputData.EntryPoint = inspectionData.EntryPoint
 	jsonOutputData.WorkDir = inspectionData.WorkDir
 	jsonOutputData.User = inspectionData.User
+	jsonOutputData.Privileges = inspectionData.Privileges
+	jsonOutputData.Env = pluginConfig.Env
+	jsonOutputData.Args = pluginConfig.Args
+	jsonOutputData.Mounts = pluginConfig.Mounts
+	jsonOutputData.Capabilities = getPluginCapabilities(pluginConfig)
+	jsonOutputData.Devices = pluginConfig.Linux.Devices
+	jsonOutputData.PropagatedMount = pluginConfig.PropagatedMount
+	jsonOutputData.NetworkType = pluginConfig.Network.Type
+	jsonOutputData.RootfsDiffIDs = pluginConfig.Rootfs.DiffIds
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Grab the Inspection and Test Results
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	jsonOutputData.Errors = inspectionData.Errors
 	jsonOutputData.Warnings = inspectionData.Warnings
-	jsonOutputData.VulnerabilitiesScanURL = inspectionData.VulnerabilitiesScanURL
+	jsonOutputData.SoakResults = inspectionData.SoakResults
+	jsonOutputData.Trace = inspectionData.Trace
+	jsonOutputData.RootfsAnalysis = inspectionData.RootfsAnalysis
+	jsonOutputData.SBOM = inspectionData.SBOM
+	jsonOutputData.Vulnerabilities = inspectionData.Vulnerabilities
+	jsonOutputData.Secrets = inspectionData.Secrets
+	jsonOutputData.Platforms = inspectionData.Platforms
 	if htmlOutput == true {
 		jsonOutputData.HTMLReportFile = inspectionData.HTMLReportFile
 	}
@@ -730,14 +943,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the DOCKER_REGISTRY_AUTH_ENDPOINT Environment variable
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	var dockerRegistryAuthEndpoint = os.Getenv("DOCKER_REGISTRY_AUTH_ENDPOINT")
-	if dockerRegistryAuthEndpoint == "" {
-		dockerRegistryAuthEndpoint = "https://auth.docker.io"
-	}
-
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Get the DOCKER_REGISTRY_API_ENDPOINT Environment variable
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -756,23 +961,93 @@ func main() {
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	dockerUserIDPtr := flag.String("docker-user", "", " Docker User ID.  This overrides the DOCKER_USER environment variable.")
 	dockerPasswordPtr := flag.String("docker-password", "", " Docker Password.  This overrides the DOCKER_PASSWORD environment variable.")
-	dockerRegistryAuthEndpointPtr := flag.String("docker-registry-auth-endpoint", dockerRegistryAuthEndpoint, " Docker Registry Authentication Endpoint. "+
-		"This overrides the DOCKER_REGISTRY_AUTH_ENDPOINT environment variable.")
 	dockerRegistryAPIEndpointPtr := flag.String("docker-registry-api-endpoint", dockerRegistryAPIEndpoint, " Docker Registry API Endpoint. "+
 		"This overrides the DOCKER_REGISTRY_API_ENDPOINT environment variable.")
+	anonymousPtr := flag.Bool("anonymous", false, " Access the registry anonymously. Only public plugins can be inspected.")
+	registryCACertPtr := flag.String("registry-ca-cert", "", " PEM encoded CA certificate trusted for the registry, in addition to the system CAs.")
+	registryClientCertPtr := flag.String("registry-client-cert", "", " PEM encoded client certificate presented to the registry. Requires --registry-client-key.")
+	registryClientKeyPtr := flag.String("registry-client-key", "", " PEM encoded key of the client certificate presented to the registry.")
+	pluginDirectoryPtr := flag.String("plugin-dir", "", " Inspect an unpublished plugin from a directory containing config.json and rootfs/. "+
+		"The plugin is created with docker plugin create under the name given as argument.")
+	pluginArchivePtr := flag.String("plugin-archive", "", " Inspect a plugin offline from an OCI image layout directory or oci-archive tarball instead of a registry. "+
+		"The plugin is created with docker plugin create under the name given as argument.")
+	registryInsecurePtr := flag.Bool("registry-insecure", false, " Do not verify the certificate of the registry and use plain HTTP if the registry does not speak HTTPS.")
 	jsonPtr := flag.Bool("json", false, " Generate JSON output.")
 	htmlPtr := flag.Bool("html", false, " Generate HTML output.")
 	helpPtr := flag.Bool("help", false, " Help on the command.")
 	verbosePtr := flag.Bool("verbose", false, " Displays more verbose output.")
+	ipamSubnetPtr := flag.String("ipam-subnet", "10.199.0.0/16", " Subnet used when testing the IPAM support of the plugin.")
+	ipamGatewayPtr := flag.String("ipam-gateway", "10.199.0.1", " Gateway used when testing the IPAM support of the plugin.")
+	ipamIPRangePtr := flag.String("ipam-ip-range", "10.199.1.0/24", " IP range used when testing the IPAM support of the plugin.")
+	ipamAuxAddressPtr := flag.String("ipam-aux-address", "reserved=10.199.0.2", " Comma separated list of name=ip auxiliary addresses used when testing the IPAM support of the plugin.")
+	ipamStaticIPPtr := flag.String("ipam-static-ip", "10.199.1.10", " Static IP address assigned to a container when testing the IPAM support of the plugin.")
+	ipv6Ptr := flag.Bool("ipv6", false, " Test the IPv6 and dual-stack support of the plugin.")
+	ipv6SubnetPtr := flag.String("ipv6-subnet", "fd00:dead:beef::/64", " IPv6 subnet used when testing the IPv6 support of the plugin.")
+	ipv6GatewayPtr := flag.String("ipv6-gateway", "fd00:dead:beef::1", " IPv6 gateway used when testing the IPv6 support of the plugin.")
+	var driverOptions stringSliceFlag
+	flag.Var(&driverOptions, "driver-opt", " Driver specific option (key=value) passed to the plugin when creating the test networks. Can be specified multiple times.")
+	var networkLabels stringSliceFlag
+	flag.Var(&networkLabels, "network-label", " Label (key=value) set on the test networks. Can be specified multiple times.")
+	soakIterationsPtr := flag.Int("soak-iterations", 0, " Number of Docker network create/delete iterations to run in the soak test. The soak test is not run by default.")
+	protocolTestPtr := flag.Bool("protocol-test", false, " Test the plugin's remote network driver API directly over its socket.")
+	recordSessionPtr := flag.String("record-session", "", " Save the plugin API session recorded during the tests to a file. Implies --trace.")
+	replaySessionPtr := flag.String("replay-session", "", " Replay a plugin API session saved with --record-session against the plugin and report responses which differ.")
+	var replayIgnoreFields stringSliceFlag
+	flag.Var(&replayIgnoreFields, "replay-ignore-field", " Response field (for example Interface.MacAddress) not compared when replaying a session. Can be specified multiple times.")
+	fuzzPtr := flag.Bool("fuzz", false, " Send malformed, oversized, truncated and type-confused payloads to the plugin's remote network driver API.")
+	tracePtr := flag.Bool("trace", false, " Record the plugin API traffic between dockerd and the plugin during the tests and include it in the report.")
+	pluginSocketPtr := flag.String("plugin-socket", "", " Path of the plugin's socket used by the protocol test. Defaults to the interface socket in the plugin's runtime directory.")
+	advisoryDatabasePtr := flag.String("advisory-db", "", " OSV advisory database (JSON file, directory of JSON files or zip file) used to scan the SBOM of the plugin for vulnerabilities.")
+	vulnerabilityErrorSeverityPtr := flag.String("vulnerability-error-severity", vulnerabilityErrorSeverity, " Vulnerabilities of this severity or higher are reported as errors (unknown, low, medium, high, critical or none).")
+	vulnerabilityWarningSeverityPtr := flag.String("vulnerability-warning-severity", vulnerabilityWarningSeverity, " Vulnerabilities of this severity or higher are reported as warnings (unknown, low, medium, high, critical or none).")
+	soakDurationPtr := flag.Duration("soak-duration", 0, " How long (for example 10m) to run the Docker network create/delete soak test. The soak test is not run by default.")
 
 	flag.Usage = usage
 	flag.Parse()
 
-	dockerAPI.DockerRegistryAuthEndpoint = *dockerRegistryAuthEndpointPtr
 	dockerAPI.DockerRegistryAPIEndpoint = *dockerRegistryAPIEndpointPtr
+	registryCACertFile = *registryCACertPtr
+	registryClientCertFile = *registryClientCertPtr
+	registryClientKeyFile = *registryClientKeyPtr
+	registryInsecure = *registryInsecurePtr
+	pluginDirectory = *pluginDirectoryPtr
+	pluginArchive = *pluginArchivePtr
 	jsonOutput = *jsonPtr
 	htmlOutput = *htmlPtr
 	inspectionData.verboseOutput = *verbosePtr
+	ipamTestOptions.Subnet = *ipamSubnetPtr
+	ipamTestOptions.Gateway = *ipamGatewayPtr
+	ipamTestOptions.IPRange = *ipamIPRangePtr
+	if *ipamAuxAddressPtr != "" {
+		ipamTestOptions.AuxAddresses = strings.Split(*ipamAuxAddressPtr, ",")
+	}
+	ipamTestStaticIPAddress = *ipamStaticIPPtr
+	ipv6Test = *ipv6Ptr
+	ipv6TestOptions.Subnet = *ipamSubnetPtr
+	ipv6TestOptions.EnableIPv6 = true
+	ipv6TestOptions.IPv6Subnet = *ipv6SubnetPtr
+	ipv6TestOptions.IPv6Gateway = *ipv6GatewayPtr
+	testNetworkOptions.DriverOptions = driverOptions
+	testNetworkOptions.Labels = networkLabels
+	ipamTestOptions.DriverOptions = driverOptions
+	ipamTestOptions.Labels = networkLabels
+	ipv6TestOptions.DriverOptions = driverOptions
+	ipv6TestOptions.Labels = networkLabels
+	soakIterations = *soakIterationsPtr
+	soakDuration = *soakDurationPtr
+	protocolTest = *protocolTestPtr
+	pluginSocketPath = *pluginSocketPtr
+	traceEnabled = *tracePtr
+	fuzzEnabled = *fuzzPtr
+	recordSessionFile = *recordSessionPtr
+	replaySessionFile = *replaySessionPtr
+	replayIgnoredFields = append(replayIgnoredFields, replayIgnoreFields...)
+	advisoryDatabasePath = *advisoryDatabasePtr
+	vulnerabilityErrorSeverity = strings.ToLower(*vulnerabilityErrorSeverityPtr)
+	vulnerabilityWarningSeverity = strings.ToLower(*vulnerabilityWarningSeverityPtr)
+	if recordSessionFile != "" {
+		traceEnabled = true
+	}
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Display the command usage if the help command line option was specified
@@ -790,6 +1065,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Verify the vulnerability severity thresholds
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if !validVulnerabilitySeverity(vulnerabilityErrorSeverity) || !validVulnerabilitySeverity(vulnerabilityWarningSeverity) {
+		logFatalError(errors.New("the vulnerability severity thresholds must be one of unknown, low, medium, high, critical or none!"))
+		os.Exit(1)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Configure the CA, the client certificate and the insecure mode of the private registry
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if err := configureRegistryTransport(); err != nil {
+		logFatalError(err)
+		os.Exit(1)
+	}
+
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Get the Docker Networking Plugin and parse it into the repo and tag
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -803,71 +1094,142 @@ func main() {
 		inspectionData.DockerNetworkingPlugin = string(match[1])
 	}
 
-	if strings.Index(inspectionData.DockerNetworkingPlugin, "/") == -1 {
-		logFatalError(errors.New("you did not prefix the Docker Networking Plugin with a user name (username/, library/ or dockerstorestaging/)!"))
+	reference, err := parseDockerReference(inspectionData.DockerNetworkingPlugin)
+	if err != nil {
+		logFatalError(err)
 		os.Exit(1)
 	}
 
-	var tagIndex = -1
-	if tagIndex = strings.LastIndex(inspectionData.DockerNetworkingPlugin, ":"); tagIndex == -1 {
-		logFatalError(errors.New("the Docker Networking Plugin does not contain a tag!"))
-		os.Exit(1)
-	}
+	inspectionData.DockerNetworkingPluginRegistry = reference.Registry
+	inspectionData.DockerNetworkingPluginRepo = reference.Repository
+	inspectionData.DockerNetworkingPluginTag = reference.Tag
+	inspectionData.DockerNetworkingPluginReferenceDigest = reference.Digest
+	inspectionData.DockerNetworkingPluginReference = formatDockerReference(reference)
+	inspectionData.DockerNetworkingPlugin = getPluginLocalName(reference)
 
-	inspectionData.DockerNetworkingPluginRepo = inspectionData.DockerNetworkingPlugin[:tagIndex]
-	inspectionData.DockerNetworkingPluginTag = inspectionData.DockerNetworkingPlugin[tagIndex+1:]
-	if inspectionData.DockerNetworkingPluginTag == "" {
-		logFatalError(errors.New("the Docker Networking Plugin does not contain a tag!"))
-		os.Exit(1)
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// A plugin directory is created under the plugin name instead of being pulled from a registry
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if pluginDirectory != "" {
+		if reference.Digest != "" {
+			logFatalError(errors.New("the plugin created from a plugin directory must be named by tag, not by digest!"))
+			os.Exit(1)
+		}
+		if match := re1.FindStringSubmatch(pluginDirectory); len(match) != 0 {
+			pluginDirectory = string(match[1])
+		}
+		if pluginDirectory, err = filepath.Abs(pluginDirectory); err != nil {
+			logFatalError(err)
+			os.Exit(1)
+		}
+		inspectionData.DockerNetworkingPluginRegistry = ""
+		inspectionData.DockerNetworkingPluginSource = "directory " + pluginDirectory
 	}
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker User ID from the command parameter. If "blank" then get the DOCKER_USER environment variable, otherwise prompt the user.
+	// A plugin archive is read from disk instead of a registry, and created under the plugin name
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	dockerUser := *dockerUserIDPtr
-	if dockerUser == "" {
-		dockerUser = os.Getenv("DOCKER_USER")
+	if pluginArchive != "" {
+		if pluginDirectory != "" {
+			logFatalError(errors.New("the --plugin-dir and --plugin-archive options can not be combined!"))
+			os.Exit(1)
+		}
+		if match := re1.FindStringSubmatch(pluginArchive); len(match) != 0 {
+			pluginArchive = string(match[1])
+		}
+		if pluginArchive, err = filepath.Abs(pluginArchive); err != nil {
+			logFatalError(err)
+			os.Exit(1)
+		}
+		if err := openPluginArchive(pluginArchive); err != nil {
+			logFatalError(err)
+			exitInspection(1)
+		}
+		inspectionData.DockerNetworkingPluginRegistry = ""
+		inspectionData.DockerNetworkingPluginSource = "archive " + pluginArchive
 	}
 
-	for dockerUser == "" {
-		fmt.Print("Enter your Docker User ID: ")
-		fmt.Scanf("%s\n", &dockerUser)
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Use the registry of the plugin reference unless a registry API endpoint was specified
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if reference.Registry != dockerHubRegistry && os.Getenv("DOCKER_REGISTRY_API_ENDPOINT") == "" && *dockerRegistryAPIEndpointPtr == dockerRegistryAPIEndpoint {
+		dockerRegistryAPIEndpoint = "https://" + reference.Registry
+		dockerAPI.DockerRegistryAPIEndpoint = dockerRegistryAPIEndpoint
 	}
+	registryHost := getRegistryHost()
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker Password from the command parameter. If "blank" then get the DOCKER_PASSWORD environment variable, otherwise prompt the user.
+	// Get the Docker User ID and Password from the command parameters. If "blank" then get the DOCKER_USER and DOCKER_PASSWORD environment variables.
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	dockerUser := *dockerUserIDPtr
+	if dockerUser == "" {
+		dockerUser = os.Getenv("DOCKER_USER")
+	}
+
 	dockerPassword := *dockerPasswordPtr
 	if dockerPassword == "" {
 		dockerPassword = os.Getenv("DOCKER_PASSWORD")
 	}
 
-	for dockerPassword == "" {
-		fmt.Print("Enter your Docker Password: ")
-		pass, err := gopass.GetPasswdMasked()
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Without a user ID or password use the Docker credential helpers and store, then prompt the user or fall back to anonymous access.
+	// The credentials are those of the registry actually contacted, which is not the registry of the reference with an API endpoint.
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	var credentials registryCredentialsStruct
+	if *anonymousPtr || pluginDirectory != "" || pluginArchive != "" {
+		credentials.Source = credentialsSourceAnonymous
+	} else if dockerUser == "" && dockerPassword == "" {
+		credentials, err = getDockerConfigCredentials(registryHost)
 		if err != nil {
 			logFatalError(err)
+			exitInspection(1)
+		}
+		if credentials.Source == "" && !stdinIsTerminal() {
+			credentials.Source = credentialsSourceAnonymous
+		}
+	}
+
+	if credentials.Source == "" {
+		for dockerUser == "" {
+			fmt.Print("Enter your Docker User ID: ")
+			fmt.Scanf("%s\n", &dockerUser)
+		}
+
+		for dockerPassword == "" {
+			fmt.Print("Enter your Docker Password: ")
+			pass, err := gopass.GetPasswdMasked()
+			if err != nil {
+				logFatalError(err)
+			}
+
+			dockerPassword = string(pass)
 		}
 
-		dockerPassword = string(pass)
+		credentials = registryCredentialsStruct{Username: dockerUser, Password: dockerPassword, Source: credentialsSourceCommandLine}
 	}
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Login to Docker
+	// Login to Docker so the plugin can be pulled. Credentials from the Docker configuration are already used by docker plugin install.
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	output, err := runCommand(fmt.Sprintf("docker login --username %s --password %s %s", dockerUser, dockerPassword, dockerRegistryAPIEndpoint))
-	if err != nil {
-		logFatalError(errors.New(err.Error() + "\n" + output))
-		os.Exit(1)
+	if credentials.Source == credentialsSourceCommandLine {
+		output, err := dockerLogin(registryHost, credentials)
+		if err != nil {
+			logFatalError(errors.New(err.Error() + "\n" + output))
+			exitInspection(1)
+		}
 	}
 
+	registryUser = credentials.Username
+	registryPassword = credentials.Password
+	registryIdentityToken = credentials.IdentityToken
+
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Get the Docker Version
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	inspectionData.SystemDockerVersion, err = runCommand("docker --version")
 	if err != nil {
 		logFatalError(errors.New(err.Error() + "\n" + inspectionData.SystemDockerVersion))
-		os.Exit(1)
+		exitInspection(1)
 	}
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -880,39 +1242,47 @@ func main() {
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	printStep("Inspecting the Docker Networking Plugin: " + inspectionData.DockerNetworkingPlugin + " ...")
 
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker Networking Plugin image Digest for the Docker Plugin
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	dockerPluginManifest = dockerAPI.DockerImageManifest{}
+	dockerPluginConfigurationBlob = dockerAPI.DockerPluginConfigurationBlob{}
+	pluginConfig = pluginConfigStruct{}
+	var pluginConfigErr error
 
-	inspectionData.DockerNetworkingPluginDigest, err = dockerAPI.GetDockerImageDigest(dockerUser, dockerPassword, inspectionData.DockerNetworkingPlugin, `plugin`)
-	if err != nil {
-		logFatalError(err)
-		os.Exit(1)
-	}
+	if pluginDirectory != "" {
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Read the configuration of the plugin directory. Its rootfs is indexed in place by the rootfs analysis.
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if err := readPluginDirectory(pluginDirectory, &dockerPluginConfigurationBlob, &pluginConfig); err != nil {
+			logFatalError(err)
+			exitInspection(1)
+		}
+	} else {
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Get the Docker Networking Plugin image Digest and Manifest. A manifest list is resolved to the manifest of the host platform.
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		inspectionData.DockerNetworkingPluginDigest, err = getPluginManifest(inspectionData.DockerNetworkingPluginRepo, getManifestReference(reference), &dockerPluginManifest)
+		if err != nil {
+			logFatalError(err)
+			exitInspection(1)
+		}
 
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker Networking Plugin image Manifest for the Docker Networking Plugin
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	dockerPluginManifest = dockerAPI.DockerImageManifest{}
-	err = dockerAPI.GetDockerImageManifest(dockerUser, dockerPassword, inspectionData.DockerNetworkingPlugin, `plugin`, &dockerPluginManifest)
-	if err != nil {
-		logFatalError(err)
-		os.Exit(1)
-	}
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Get the Docker Docker Networking Plugin image's base layer image digest
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		inspectionData.DockerNetworkingPluginBaseLayerImageDigest = dockerPluginManifest.Layers[0].Digest
 
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker Docker Networking Plugin image's base layer image digest
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	inspectionData.DockerNetworkingPluginBaseLayerImageDigest = dockerPluginManifest.Layers[0].Digest
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Get the Docker Configuration Blob for the Docker Networking Plugin
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		configBlob, err := getPluginConfigurationBlob(inspectionData.DockerNetworkingPluginRepo, dockerPluginManifest.Config.Digest, &dockerPluginConfigurationBlob)
+		if err != nil {
+			logFatalError(err)
+			exitInspection(1)
+		}
 
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// Get the Docker Configuration Blob for the Docker Networking Plugin
-	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	dockerPluginConfigurationBlob = dockerAPI.DockerPluginConfigurationBlob{}
-	err = dockerAPI.GetDockerPluginConfigBlob(dockerUser, dockerPassword, inspectionData.DockerNetworkingPlugin, &dockerPluginConfigurationBlob)
-	if err != nil {
-		logFatalError(err)
-		os.Exit(1)
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Get the full plugin configuration from the raw config blob
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		pluginConfigErr = decodePluginConfig(configBlob, &pluginConfig)
 	}
 
 	successMessage := fmt.Sprintf("Docker Networking Plugin image %s has been inspected.", inspectionData.DockerNetworkingPlugin)
@@ -922,7 +1292,7 @@ func main() {
 	inspectionData.Description = dockerPluginConfigurationBlob.Description
 	inspectionData.Documentation = dockerPluginConfigurationBlob.Documentation
 	inspectionData.InterfaceSocket = dockerPluginConfigurationBlob.Interface.Socket
-	inspectionData.InterfaceSocketTypes = strings.Join(dockerPluginConfigurationBlob.Interface.Types, "")
+	inspectionData.InterfaceSocketTypes = strings.Join(dockerPluginConfigurationBlob.Interface.Types, ", ")
 	inspectionData.IpcHost = strconv.FormatBool(dockerPluginConfigurationBlob.IpcHost)
 	inspectionData.PidHost = strconv.FormatBool(dockerPluginConfigurationBlob.PidHost)
 	inspectionData.WorkDir = dockerPluginConfigurationBlob.WorkDir
@@ -931,6 +1301,8 @@ func main() {
 		inspectionData.User += user + " "
 	}
 
+	inspectionData.PluginConfiguration = getPluginConfigInformation(pluginConfig)
+
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Print the Docker Networking Plugin Information
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -941,10 +1313,19 @@ func main() {
 
 	printMessage(separator)
 	printMessage(fmt.Sprintf(lineFormat, "Docker Networking Plugin:", inspectionData.DockerNetworkingPlugin))
+	printMessage(fmt.Sprintf(lineFormat, "Registry:", inspectionData.DockerNetworkingPluginRegistry))
+	if inspectionData.DockerNetworkingPluginReferenceDigest != "" {
+		printMessage(fmt.Sprintf(lineFormat, "Pinned digest:", inspectionData.DockerNetworkingPluginReferenceDigest))
+	}
+	if inspectionData.DockerNetworkingPluginSource != "" {
+		printMessage(fmt.Sprintf(lineFormat, "Source:", truncateString(inspectionData.DockerNetworkingPluginSource, termImageInformationLineLength)))
+	}
 	printMessage(fmt.Sprintf(lineFormat, "Description:", inspectionData.Description))
 	printMessage(fmt.Sprintf(lineFormat, "Documentation:", inspectionData.Documentation))
-	printMessage(fmt.Sprintf(lineFormat, "Digest:", inspectionData.DockerNetworkingPluginDigest))
-	printMessage(fmt.Sprintf(lineFormat, "Base layer digest:", inspectionData.DockerNetworkingPluginBaseLayerImageDigest))
+	if inspectionData.DockerNetworkingPluginDigest != "" {
+		printMessage(fmt.Sprintf(lineFormat, "Digest:", inspectionData.DockerNetworkingPluginDigest))
+		printMessage(fmt.Sprintf(lineFormat, "Base layer digest:", inspectionData.DockerNetworkingPluginBaseLayerImageDigest))
+	}
 
 	if inspectionData.DockerNetworkingPluginDockerVersion != "" {
 		printMessage(fmt.Sprintf(lineFormat, "Docker version:", inspectionData.DockerNetworkingPluginDockerVersion))
@@ -957,21 +1338,220 @@ func main() {
 	printMessage(fmt.Sprintf(lineFormat, "Entrypoint:", truncateString(inspectionData.EntryPoint, termImageInformationLineLength)))
 	printMessage(fmt.Sprintf(lineFormat, "WorkDir:", inspectionData.WorkDir))
 	printMessage(fmt.Sprintf(lineFormat, "User:", inspectionData.User))
+	for _, configInformation := range inspectionData.PluginConfiguration {
+		label := configInformation.Label + ":"
+		if len(configInformation.Values) == 0 {
+			printMessage(fmt.Sprintf(lineFormat, label, ""))
+		}
+		for _, value := range configInformation.Values {
+			printMessage(fmt.Sprintf(lineFormat, label, truncateString(value, termImageInformationLineLength)))
+			label = ""
+		}
+	}
 	printMessage(separator)
 
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Make sure the plugin is a networking plugin and find out which test suites apply to it
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Checking the interface types of the Docker Networking Plugin")
+
+	networkDriverPlugin, ipamDriverPlugin := checkPluginInterfaceTypes(inspectionData.DockerNetworkingPlugin, dockerPluginConfigurationBlob.Interface.Types)
+	if !networkDriverPlugin && (protocolTest || fuzzEnabled || replaySessionFile != "") {
+		printWarning("The remote network driver API tests (--protocol-test, --fuzz and --replay-session) are skipped, the plugin is not a network driver.")
+		protocolTest = false
+		fuzzEnabled = false
+		replaySessionFile = ""
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Check the privileges the plugin will be granted when it is installed
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Checking the privileges requested by the Docker Networking Plugin")
+
+	if pluginConfigErr != nil {
+		printError("Unable to get the configuration of the Docker Networking Plugin! The privileges requested by the plugin were not checked. " + pluginConfigErr.Error())
+	} else {
+		lintPluginConfig(pluginConfig)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Download the plugin rootfs and look inside the image before it is run as root
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Analyzing the rootfs of the Docker Networking Plugin")
+
+	var layerDigests []string
+	for _, layer := range dockerPluginManifest.Layers {
+		layerDigests = append(layerDigests, layer.Digest)
+	}
+
+	if pluginConfigErr != nil {
+		analyzePluginRootfs(inspectionData.DockerNetworkingPluginRepo, layerDigests, dockerPluginConfigurationBlob.Entrypoint, dockerPluginConfigurationBlob.WorkDir, nil)
+	} else {
+		analyzePluginRootfs(inspectionData.DockerNetworkingPluginRepo, layerDigests, pluginConfig.Entrypoint, pluginConfig.WorkDir, pluginConfig.Env)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Generate the software bill of materials from the unpacked rootfs
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Generating the software bill of materials of the Docker Networking Plugin")
+
+	if pluginRootfsDirectory == "" {
+		printError("Unable to generate the software bill of materials! The rootfs of the plugin could not be unpacked.")
+	} else {
+		generatePluginSBOM()
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Scan the packages of the software bill of materials for known vulnerabilities
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Scanning the software bill of materials of the Docker Networking Plugin for vulnerabilities")
+
+	if advisoryDatabasePath == "" {
+		printWarning("No advisory database was specified with --advisory-db. The packages of the plugin were not scanned for vulnerabilities.")
+	} else if inspectionData.SBOM == nil {
+		printError("Unable to scan the plugin for vulnerabilities! The software bill of materials could not be generated.")
+	} else {
+		scanPluginVulnerabilities(advisoryDatabasePath)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Look for private keys, tokens, passwords and cloud credentials shipped with the plugin
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	printStep("Scanning the Docker Networking Plugin configuration and rootfs for secrets")
+
+	if pluginConfigErr != nil {
+		printWarning("The plugin configuration could not be downloaded. The Env and Args of the plugin were not scanned for secrets.")
+	}
+	if pluginRootfsDirectory == "" {
+		printWarning("The rootfs of the plugin could not be unpacked. The files of the plugin were not scanned for secrets.")
+	}
+	scanPluginSecrets(pluginConfig)
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Initialize swarm mode (needed by plugins with a global scope) before taking the host networking state baseline. A plugin
+	// rejected by the interface type check is not installed, so the host is not turned into a swarm manager for it.
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if networkDriverPlugin || ipamDriverPlugin {
+		runCommand("docker swarm init")
+	}
+
+	hostNetworkStateBeforeInstall := snapshotHostNetworkState()
+
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Install the Docker Networking Plugin		/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	if installDockerNetworkingPlugin(inspectionData.DockerNetworkingPlugin) {
+	if (networkDriverPlugin || ipamDriverPlugin) && installDockerNetworkingPlugin(inspectionData.DockerNetworkingPlugin) {
+		hostNetworkStateAfterInstall := snapshotHostNetworkState()
+		if inspectionData.verboseOutput {
+			printHostNetworkStateChanges(hostNetworkStateBeforeInstall, hostNetworkStateAfterInstall, "installing the plugin")
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Replay a recorded plugin API session against the freshly installed plugin if requested
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if replaySessionFile != "" {
+			printStep("Replaying the plugin API session " + replaySessionFile + " against plugin: " + inspectionData.DockerNetworkingPlugin + " ...")
+
+			if ok := replayPluginSession(inspectionData.DockerNetworkingPlugin, replaySessionFile); !ok {
+				printError("Docker Network Plugin Test has failed! The plugin responses differ from the recorded session: " + inspectionData.DockerNetworkingPlugin)
+			}
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Start recording the plugin API traffic if requested
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		var traceProxy *pluginTraceProxyStruct
+		if traceEnabled {
+			traceProxy, err = startPluginTraceProxy(inspectionData.DockerNetworkingPlugin)
+			if err != nil {
+				printError("Unable to start recording the plugin API traffic! " + err.Error())
+			}
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Test the remote network driver API directly over the plugin's socket if requested
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if protocolTest {
+			printStep("Testing the remote network driver API of plugin: " + inspectionData.DockerNetworkingPlugin + " ...")
+
+			if ok := runRemoteDriverProtocolTest(inspectionData.DockerNetworkingPlugin); !ok {
+				printError("Docker Network Plugin Test has failed! The plugin does not conform to the remote network driver API: " + inspectionData.DockerNetworkingPlugin)
+			}
+		}
+
 		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 		// Now run the Networking Plugin Tests
 		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 		//runNetworkingPluginTest()
-		runNetworkingPluginTest(inspectionData.DockerNetworkingPlugin)
+		if networkDriverPlugin {
+			runNetworkingPluginTest(inspectionData.DockerNetworkingPlugin)
+		}
+		if ipamDriverPlugin {
+			runIPAMPluginTest(inspectionData.DockerNetworkingPlugin)
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Stop recording the plugin API traffic
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if traceProxy != nil {
+			printStep("Plugin API trace")
+
+			inspectionData.Trace, err = traceProxy.stop()
+			if err != nil {
+				printError("Unable to move the plugin socket back to its original path! " + err.Error())
+			}
+			if len(inspectionData.Trace) == 0 {
+				printWarning(fmt.Sprintf("No plugin API calls between dockerd and the plugin %s were recorded! dockerd reused the connections it opened to the plugin before the trace started.", inspectionData.DockerNetworkingPlugin))
+			} else {
+				printSuccess(fmt.Sprintf("Recorded %d plugin API calls between dockerd and the plugin %s", len(inspectionData.Trace), inspectionData.DockerNetworkingPlugin))
+			}
+			if inspectionData.verboseOutput {
+				printPluginAPITrace(inspectionData.Trace)
+			}
+
+			if recordSessionFile != "" {
+				if err := savePluginSession(recordSessionFile, inspectionData.DockerNetworkingPlugin, inspectionData.Trace); err != nil {
+					printError("Unable to save the plugin API session to " + recordSessionFile + "! " + err.Error())
+				} else {
+					printSuccess("Saved the plugin API session to " + recordSessionFile)
+				}
+			}
+		}
+
+		hostNetworkStateAfterTest := snapshotHostNetworkState()
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Fuzz the remote network driver API of the plugin if requested
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if fuzzEnabled {
+			printStep("Fuzzing the remote network driver API of plugin: " + inspectionData.DockerNetworkingPlugin + " ...")
+
+			if ok := runPluginAPIFuzzer(inspectionData.DockerNetworkingPlugin); !ok {
+				printError("Docker Network Plugin Test has failed! The plugin does not handle malformed API requests safely: " + inspectionData.DockerNetworkingPlugin)
+			}
+		}
+
 		//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 		// Remove the Docker Networking Plugin if it was installed
 		//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 		printStep("Removing the Docker networking plugin")
 		removeDockerNetworkingPlugin(inspectionData.DockerNetworkingPlugin)
+
+		hostNetworkStateAfterRemove := snapshotHostNetworkState()
+
+		//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Make sure the tests and the plugin removal left the host networking state as it was
+		//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		printStep("Checking the host networking state for anything left behind by the plugin")
+
+		if !hostNetworkStateSupported() {
+			printWarning(fmt.Sprintf("The host networking state can not be checked on %s.", runtime.GOOS))
+		} else {
+			testsClean := compareHostNetworkState(hostNetworkStateAfterInstall, hostNetworkStateAfterTest, "running the network tests", false)
+			removeClean := compareHostNetworkState(hostNetworkStateBeforeInstall, hostNetworkStateAfterRemove, "removing the plugin", true)
+			if testsClean && removeClean {
+				printSuccess(fmt.Sprintf("The host networking state was left as it was prior to installing the plugin %s", inspectionData.DockerNetworkingPlugin))
+			}
+		}
 	}
 
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -993,14 +1573,6 @@ func main() {
 
 	printMessage(fmt.Sprintf("The inspection of the Docker networking plugin %s has completed.", inspectionData.DockerNetworkingPlugin))
 
-	// If the Docker Networking plugin being inspection is in dockertorestaging then display the vulnerabilities scan URL
-
-	// If the Docker Image being inspected is in dockerstorestaging then display the vulnerabilities scan URL
-	if strings.HasPrefix(inspectionData.DockerNetworkingPluginRepo, "dockerstorestaging/") {
-		inspectionData.VulnerabilitiesScanURL = fmt.Sprintf("https://cloud.docker.com/app/dockerstorestaging/repository/docker/%s/tags/%s", inspectionData.DockerNetworkingPluginRepo, inspectionData.DockerNetworkingPluginTag)
-		printMessage(fmt.Sprintf("\nVulnerabilities scan report URL: %s", inspectionData.VulnerabilitiesScanURL))
-	}
-
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Generate the HTML Report if HTML Output was requested
 	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -1017,7 +1589,7 @@ func main() {
 
 	printMessage("")
 
-	os.Exit(exitCode)
+	exitInspection(exitCode)
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -1027,8 +1599,6 @@ func installDockerNetworkingPlugin(dockerNetworkingPlugin string) bool {
 	var output string
 	var err error
 
-	runCommand("docker swarm init")
-
 	printStep(fmt.Sprintf("Installing the Docker Networking plugin %s ...", inspectionData.DockerNetworkingPlugin))
 
 	if dockerNetworkingPluginInstalled(dockerNetworkingPlugin) {
@@ -1036,7 +1606,29 @@ func installDockerNetworkingPlugin(dockerNetworkingPlugin string) bool {
 		removeDockerNetworkingPlugin(dockerNetworkingPlugin)
 	}
 
-	output, err = runCommand("docker plugin install --grant-all-permissions " + dockerNetworkingPlugin)
+	if pluginDirectory != "" {
+		return createDockerNetworkingPlugin(dockerNetworkingPlugin, pluginDirectory)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// A plugin read from a plugin archive is created from its config blob and unpacked rootfs, it can not be pulled
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if pluginArchive != "" {
+		directory, err := preparePluginArchiveDirectory(dockerPluginManifest.Config.Digest)
+		if err != nil {
+			printError("Unable to prepare the Docker Networking Plugin of the plugin archive for docker plugin create! " + err.Error())
+			return false
+		}
+		return createDockerNetworkingPlugin(dockerNetworkingPlugin, directory)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// The plugin is pulled by the inspected digest from the registry it was inspected in, and installed under its local name
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	checkDockerRegistryTrust()
+	var pluginReference = "--alias " + shellQuote(dockerNetworkingPlugin) + " " + shellQuote(getPluginPullReference())
+
+	output, err = runCommand("docker plugin install --grant-all-permissions " + pluginReference)
 	if err != nil {
 		var errMessage = "Unable to install the Docker Networking Plugin!"
 		if output != "" {
@@ -1049,14 +1641,19 @@ func installDockerNetworkingPlugin(dockerNetworkingPlugin string) bool {
 	}
 
 	printSuccess(fmt.Sprintf("Docker networking plugin %s has been installed successfully.", inspectionData.DockerNetworkingPlugin))
-	return true
-}
 
-//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if !verifyInstalledPluginDigest(dockerNetworkingPlugin) {
+		removeDockerNetworkingPlugin(dockerNetworkingPlugin)
+		return false
+	}
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Removes the Docker Networking Plugin
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func removeDockerNetworkingPlugin(pluginName string) bool {
-	output, err := runCommand("docker plugin remove " + pluginName + " --force")
+	output, err := runCommand("docker plugin remove " + shellQuote(pluginName) + " --force")
 	if err != nil {
 		var errMessage = fmt.Sprintf("Unable to remove the Docker networking plugin %s!", pluginName)
 		if output != "" {
@@ -1075,11 +1672,16 @@ func removeDockerNetworkingPlugin(pluginName string) bool {
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Creates the Docker Network
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-func createDockerNetwork(pluginName string) bool {
+func createDockerNetwork(pluginName string, networkOptions dockerNetworkOptionsStruct) bool {
 
 	runCommand("docker network rm test_network")
 	time.Sleep(1 * time.Second)
-	output, err := runCommand("docker network create --driver=" + pluginName + " " + testNetworkName)
+	var driver = pluginName
+	if networkOptions.Driver != "" {
+		driver = networkOptions.Driver
+	}
+
+	output, err := runCommand("docker network create --driver=" + shellQuote(driver) + getDockerNetworkCreateOptions(networkOptions) + " " + testNetworkName)
 	if err != nil {
 		var errMessage = fmt.Sprintf("Unable to create a Docker network using plugin %s!", pluginName)
 		if output != "" {
@@ -1096,6 +1698,46 @@ func createDockerNetwork(pluginName string) bool {
 	return true
 }
 
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the docker network create command line options for the passed network options
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerNetworkCreateOptions(networkOptions dockerNetworkOptionsStruct) string {
+	var options string
+
+	if networkOptions.IPAMDriver != "" {
+		options += " --ipam-driver=" + shellQuote(networkOptions.IPAMDriver)
+	}
+	if networkOptions.Subnet != "" {
+		options += " --subnet=" + shellQuote(networkOptions.Subnet)
+	}
+	if networkOptions.Gateway != "" {
+		options += " --gateway=" + shellQuote(networkOptions.Gateway)
+	}
+	if networkOptions.IPRange != "" {
+		options += " --ip-range=" + shellQuote(networkOptions.IPRange)
+	}
+	for _, auxAddress := range networkOptions.AuxAddresses {
+		options += " --aux-address=" + shellQuote(strings.TrimSpace(auxAddress))
+	}
+	if networkOptions.EnableIPv6 {
+		options += " --ipv6"
+	}
+	if networkOptions.IPv6Subnet != "" {
+		options += " --subnet=" + shellQuote(networkOptions.IPv6Subnet)
+	}
+	if networkOptions.IPv6Gateway != "" {
+		options += " --gateway=" + shellQuote(networkOptions.IPv6Gateway)
+	}
+	for _, driverOption := range networkOptions.DriverOptions {
+		options += " --opt " + shellQuote(driverOption)
+	}
+	for _, label := range networkOptions.Labels {
+		options += " --label " + shellQuote(label)
+	}
+
+	return options
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // Removes the Docker Test Network
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -1122,7 +1764,7 @@ func removeDockerNetwork(pluginName string) bool {
 // Checks to see if the Docker Networking Plugin is installed
 //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 func dockerNetworkingPluginInstalled(dockerNetworkPlugin string) bool {
-	_, err := runCommand("docker plugin inspect --format '{{ .Name }}' " + dockerNetworkPlugin)
+	_, err := runCommand("docker plugin inspect --format '{{ .Name }}' " + shellQuote(dockerNetworkPlugin))
 	if err != nil {
 		return false
 	}
@@ -1142,8 +1784,18 @@ func runNetworkingPluginTest(pluginName string) {
 	//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	printStep("Testing the Docker network creation using plugin: " + pluginName + " ...")
 
-	if ok := createDockerNetwork(pluginName); !ok {
+	if ok := createDockerNetwork(pluginName, testNetworkOptions); !ok {
 		printError("Docker Network Plugin Test has failed! Unable to create a Docker network using the plugin: " + pluginName)
+	} else {
+		if ok := checkDockerNetworkOptions(testNetworkOptions); !ok {
+			printError("Docker Network Plugin Test has failed! The driver options or labels were not applied to a Docker network using the plugin: " + pluginName)
+		}
+
+		printStep("Testing container connectivity on the Docker network using plugin: " + pluginName + " ...")
+
+		if ok := testContainerConnectivity(pluginName); !ok {
+			printError("Docker Network Plugin Test has failed! Containers are unable to communicate on a Docker network using the plugin: " + pluginName)
+		}
 	}
 
 	printStep("Testing the Docker network deletion using plugin: " + pluginName + " ...")
@@ -1151,4 +1803,634 @@ func runNetworkingPluginTest(pluginName string) {
 	if ok := removeDockerNetwork(pluginName); !ok {
 		printError("Docker Network Plugin Test has failed! Unable to delete a Docker network using the plugin: " + pluginName)
 	}
+
+	printStep("Testing the Docker network connect and disconnect of a running container using plugin: " + pluginName + " ...")
+
+	if ok := testNetworkConnectDisconnect(pluginName); !ok {
+		printError("Docker Network Plugin Test has failed! Unable to connect or disconnect a running container using the plugin: " + pluginName)
+	}
+
+	printStep("Testing the Docker network IPAM support using plugin: " + pluginName + " ...")
+
+	if ok := testNetworkIPAM(pluginName, ipamTestOptions); !ok {
+		printError("Docker Network Plugin Test has failed! The IPAM settings were not honored by the plugin: " + pluginName)
+	}
+
+	if ipv6Test {
+		printStep("Testing the Docker network IPv6 and dual-stack support using plugin: " + pluginName + " ...")
+
+		if ok := testNetworkIPv6(pluginName); !ok {
+			printError("Docker Network Plugin Test has failed! IPv6 is not working on a Docker network using the plugin: " + pluginName)
+		}
+	}
+
+	if soakTestRequested() {
+		printStep("Running the Docker network create/delete soak test using plugin: " + pluginName + " ...")
+
+		if ok := runSoakTest(pluginName); !ok {
+			printError("Docker Network Plugin Test has failed! Docker network create/delete operations failed during the soak test using the plugin: " + pluginName)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Starts a test container attached to the specified Docker network. The container serves a small page over HTTP so it can be used as a TCP target.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runTestContainer(containerName string, networkName string, containerOptions string) bool {
+	runCommand("docker rm --force " + containerName)
+
+	output, err := runCommand("docker run --detach --name " + containerName + " --network " + networkName + " " + containerOptions + " " + testContainerImage +
+		" sh -c 'echo ok > /tmp/index.html && exec httpd -f -p " + testContainerTCPPort + " -h /tmp'")
+	if err != nil {
+		var errMessage = fmt.Sprintf("Unable to start the container %s on the Docker network %s!", containerName, networkName)
+		if output != "" {
+			errMessage = errMessage + ", " + output
+		} else {
+			errMessage = errMessage + ", " + err.Error()
+		}
+		printError(errMessage)
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("Container %s was started on the Docker network %s", containerName, networkName))
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes a test container
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func removeTestContainer(containerName string) bool {
+	output, err := runCommand("docker rm --force " + containerName)
+	if err != nil {
+		var errMessage = fmt.Sprintf("Unable to remove the container %s!", containerName)
+		if output != "" {
+			errMessage = errMessage + ", " + output
+		} else {
+			errMessage = errMessage + ", " + err.Error()
+		}
+		printError(errMessage)
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("Container %s was removed", containerName))
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the IP address assigned to the container on the specified Docker network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getContainerIPAddress(containerName string, networkName string) (string, error) {
+	return getContainerNetworkSetting(containerName, networkName, "IPAddress")
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a setting (IPAddress, GlobalIPv6Address, IPv6Gateway, ...) of the container's endpoint on the specified Docker network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getContainerNetworkSetting(containerName string, networkName string, setting string) (string, error) {
+	output, err := runCommand("docker inspect --format '{{with index .NetworkSettings.Networks \"" + networkName + "\"}}{{." + setting + "}}{{end}}' " + containerName)
+	if err != nil {
+		return "", errors.New(err.Error() + ", " + output)
+	}
+
+	return output, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the name of the interface inside the container which holds the specified IP address
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getContainerInterfaceName(containerName string, ipAddress string) (string, error) {
+	output, err := runCommand("docker exec " + containerName + " ip -o addr show")
+	if err != nil {
+		return "", errors.New(err.Error() + ", " + output)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Each line looks like: 12: eth0    inet 10.32.0.1/12 brd 10.47.255.255 scope global eth0
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		if strings.Split(fields[3], "/")[0] == ipAddress {
+			return strings.Split(fields[1], "@")[0], nil
+		}
+	}
+
+	return "", nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Starts test containers on the test network and verifies they were wired up by the plugin and can reach each other over ICMP and TCP
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testContainerConnectivity(pluginName string) bool {
+	var passed = true
+	var ipAddresses = map[string]string{}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Remove the test containers when the test completes
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	defer func() {
+		for _, containerName := range testContainerNames {
+			removeTestContainer(containerName)
+		}
+	}()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Start the test containers and check each one got an interface and an IP address from the plugin
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for _, containerName := range testContainerNames {
+		if ok := runTestContainer(containerName, testNetworkName, ""); !ok {
+			return false
+		}
+
+		ipAddress, err := getContainerIPAddress(containerName, testNetworkName)
+		if err != nil || ipAddress == "" {
+			var errMessage = fmt.Sprintf("Container %s was not assigned an IP address on the Docker network using plugin %s!", containerName, pluginName)
+			if err != nil {
+				errMessage = errMessage + ", " + err.Error()
+			}
+			printError(errMessage)
+			return false
+		}
+
+		interfaceName, err := getContainerInterfaceName(containerName, ipAddress)
+		if err != nil || interfaceName == "" {
+			var errMessage = fmt.Sprintf("Container %s does not have an interface with the IP address %s!", containerName, ipAddress)
+			if err != nil {
+				errMessage = errMessage + ", " + err.Error()
+			}
+			printError(errMessage)
+			return false
+		}
+
+		printSuccess(fmt.Sprintf("Container %s has interface %s with IP address %s on the Docker network using plugin %s", containerName, interfaceName, ipAddress, pluginName))
+		ipAddresses[containerName] = ipAddress
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Check the test containers can reach each other over ICMP and TCP
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for _, sourceContainer := range testContainerNames {
+		for _, targetContainer := range testContainerNames {
+			if sourceContainer == targetContainer {
+				continue
+			}
+			targetIPAddress := ipAddresses[targetContainer]
+
+			output, err := runCommand("docker exec " + sourceContainer + " ping -c 3 -W 2 " + targetIPAddress)
+			if err != nil {
+				printError(fmt.Sprintf("Container %s is unable to reach container %s (%s) over ICMP!, %s", sourceContainer, targetContainer, targetIPAddress, output))
+				passed = false
+			} else {
+				printSuccess(fmt.Sprintf("Container %s reached container %s (%s) over ICMP", sourceContainer, targetContainer, targetIPAddress))
+			}
+
+			output, err = runCommand("docker exec " + sourceContainer + " wget -q -O - -T 5 http://" + targetIPAddress + ":" + testContainerTCPPort + "/")
+			if err != nil || output != "ok" {
+				printError(fmt.Sprintf("Container %s is unable to reach container %s (%s) over TCP port %s!, %s", sourceContainer, targetContainer, targetIPAddress, testContainerTCPPort, output))
+				passed = false
+			} else {
+				printSuccess(fmt.Sprintf("Container %s reached container %s (%s) over TCP port %s", sourceContainer, targetContainer, targetIPAddress, testContainerTCPPort))
+			}
+		}
+	}
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the IPAM configuration of the Docker test network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerNetworkIPAMConfig() ([]dockerNetworkIPAMConfigStruct, error) {
+	var ipamConfig []dockerNetworkIPAMConfigStruct
+
+	output, err := runCommand("docker network inspect --format '{{json .IPAM.Config}}' " + testNetworkName)
+	if err != nil {
+		return nil, errors.New(err.Error() + ", " + output)
+	}
+
+	err = json.Unmarshal([]byte(output), &ipamConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipamConfig, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the driver options or labels (depending on the passed field) of the Docker test network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDockerNetworkKeyValues(field string) (map[string]string, error) {
+	var keyValues map[string]string
+
+	output, err := runCommand("docker network inspect --format '{{json ." + field + "}}' " + testNetworkName)
+	if err != nil {
+		return nil, errors.New(err.Error() + ", " + output)
+	}
+
+	err = json.Unmarshal([]byte(output), &keyValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return keyValues, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Verifies the requested key=value pairs are reflected in the driver options or labels (depending on the passed field) of the Docker test network
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func checkDockerNetworkKeyValues(field string, description string, requested []string) bool {
+	var passed = true
+
+	if len(requested) == 0 {
+		return true
+	}
+
+	keyValues, err := getDockerNetworkKeyValues(field)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to inspect the %ss of the Docker network! %s", description, err.Error()))
+		return false
+	}
+
+	for _, keyValue := range requested {
+		keyValueParts := strings.SplitN(keyValue, "=", 2)
+		key := keyValueParts[0]
+		value := ""
+		if len(keyValueParts) == 2 {
+			value = keyValueParts[1]
+		}
+
+		actual, found := keyValues[key]
+		if !found {
+			printError(fmt.Sprintf("The Docker network %s %s is missing!", description, key))
+			passed = false
+		} else if actual != value {
+			printError(fmt.Sprintf("The Docker network %s %s was not honored! Requested: %s, Actual: %s", description, key, value, actual))
+			passed = false
+		} else {
+			printSuccess(fmt.Sprintf("The Docker network %s %s=%s was applied", description, key, value))
+		}
+	}
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Verifies the driver options and labels requested when creating the network are reflected in docker network inspect
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func checkDockerNetworkOptions(networkOptions dockerNetworkOptionsStruct) bool {
+	driverOptionsPassed := checkDockerNetworkKeyValues("Options", "driver option", networkOptions.DriverOptions)
+	labelsPassed := checkDockerNetworkKeyValues("Labels", "label", networkOptions.Labels)
+
+	return driverOptionsPassed && labelsPassed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares an IPAM setting requested when creating the network to what the plugin reported back
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func checkIPAMSetting(setting string, requested string, actual string) bool {
+	if requested != actual {
+		printError(fmt.Sprintf("The Docker network %s was not honored! Requested: %s, Actual: %s", setting, requested, actual))
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("The Docker network %s %s was honored", setting, requested))
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Creates the Docker test network with a subnet, gateway, ip range and auxiliary addresses and verifies the plugin honors them
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testNetworkIPAM(pluginName string, networkOptions dockerNetworkOptionsStruct) bool {
+	var passed = true
+
+	if ok := createDockerNetwork(pluginName, networkOptions); !ok {
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Remove the test containers and the test network when the test completes
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	defer func() {
+		for _, containerName := range testContainerNames {
+			removeTestContainer(containerName)
+		}
+		removeDockerNetwork(pluginName)
+	}()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Find the IPAM configuration entry for the requested subnet
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	ipamConfigs, err := getDockerNetworkIPAMConfig()
+	if err != nil {
+		printError("Unable to inspect the IPAM configuration of the Docker network! " + err.Error())
+		return false
+	}
+
+	var ipamConfig *dockerNetworkIPAMConfigStruct
+	for index := range ipamConfigs {
+		if ipamConfigs[index].Subnet == networkOptions.Subnet {
+			ipamConfig = &ipamConfigs[index]
+		}
+	}
+
+	if ipamConfig == nil {
+		printError(fmt.Sprintf("The Docker network subnet %s was not honored! The network does not contain the subnet.", networkOptions.Subnet))
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("The Docker network subnet %s was honored", networkOptions.Subnet))
+	passed = checkIPAMSetting("gateway", networkOptions.Gateway, ipamConfig.Gateway) && passed
+	passed = checkIPAMSetting("ip range", networkOptions.IPRange, ipamConfig.IPRange) && passed
+
+	for _, auxAddress := range networkOptions.AuxAddresses {
+		auxAddressParts := strings.SplitN(strings.TrimSpace(auxAddress), "=", 2)
+		if len(auxAddressParts) != 2 {
+			continue
+		}
+		passed = checkIPAMSetting("auxiliary address "+auxAddressParts[0], auxAddressParts[1], ipamConfig.AuxiliaryAddresses[auxAddressParts[0]]) && passed
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Start a container with a static IP address and make sure it gets exactly that address
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if ok := runTestContainer(testContainerNames[0], testNetworkName, "--ip "+shellQuote(ipamTestStaticIPAddress)); !ok {
+		return false
+	}
+
+	ipAddress, err := getContainerIPAddress(testContainerNames[0], testNetworkName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to get the IP address of container %s! %s", testContainerNames[0], err.Error()))
+		return false
+	}
+
+	passed = checkIPAMSetting("static container IP address", ipamTestStaticIPAddress, ipAddress) && passed
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Start a container without a static IP address and make sure it gets an address from the ip range
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if ok := runTestContainer(testContainerNames[1], testNetworkName, ""); !ok {
+		return false
+	}
+
+	ipAddress, err = getContainerIPAddress(testContainerNames[1], testNetworkName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to get the IP address of container %s! %s", testContainerNames[1], err.Error()))
+		return false
+	}
+
+	_, ipRange, err := net.ParseCIDR(networkOptions.IPRange)
+	if err == nil {
+		if ip := net.ParseIP(ipAddress); ip == nil || !ipRange.Contains(ip) {
+			printError(fmt.Sprintf("Container %s was assigned the IP address %s which is outside of the ip range %s!", testContainerNames[1], ipAddress, networkOptions.IPRange))
+			passed = false
+		} else {
+			printSuccess(fmt.Sprintf("Container %s was assigned the IP address %s from the ip range %s", testContainerNames[1], ipAddress, networkOptions.IPRange))
+		}
+	}
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Creates a dual-stack Docker test network and verifies the IPv6 addresses, the IPv6 gateway and IPv6 reachability between containers
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testNetworkIPv6(pluginName string) bool {
+	var passed = true
+	var ipv6Addresses = map[string]string{}
+
+	if ok := createDockerNetwork(pluginName, ipv6TestOptions); !ok {
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Remove the test containers and the test network when the test completes
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	defer func() {
+		for _, containerName := range testContainerNames {
+			removeTestContainer(containerName)
+		}
+		removeDockerNetwork(pluginName)
+	}()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Make sure the network was created with the IPv6 subnet and gateway
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	output, err := runCommand("docker network inspect --format '{{.EnableIPv6}}' " + testNetworkName)
+	if err != nil || output != "true" {
+		printError(fmt.Sprintf("IPv6: The Docker network was not created with IPv6 enabled using plugin %s! %s", pluginName, output))
+		return false
+	}
+
+	ipamConfigs, err := getDockerNetworkIPAMConfig()
+	if err != nil {
+		printError("IPv6: Unable to inspect the IPAM configuration of the Docker network! " + err.Error())
+		return false
+	}
+
+	var ipv6Gateway string
+	var ipv6SubnetFound = false
+	for _, ipamConfig := range ipamConfigs {
+		if ipamConfig.Subnet == ipv6TestOptions.IPv6Subnet {
+			ipv6SubnetFound = true
+			ipv6Gateway = ipamConfig.Gateway
+		}
+	}
+
+	if !ipv6SubnetFound {
+		printError(fmt.Sprintf("IPv6: The Docker network does not contain the IPv6 subnet %s!", ipv6TestOptions.IPv6Subnet))
+		return false
+	}
+	printSuccess(fmt.Sprintf("IPv6: The Docker network contains the IPv6 subnet %s", ipv6TestOptions.IPv6Subnet))
+
+	if ipv6TestOptions.IPv6Gateway != "" && ipv6Gateway != ipv6TestOptions.IPv6Gateway {
+		printError(fmt.Sprintf("IPv6: The Docker network IPv6 gateway was not honored! Requested: %s, Actual: %s", ipv6TestOptions.IPv6Gateway, ipv6Gateway))
+		passed = false
+	} else {
+		printSuccess(fmt.Sprintf("IPv6: The Docker network IPv6 gateway is %s", ipv6Gateway))
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Start the test containers and check each one got an IPv4 and an IPv6 address and the IPv6 gateway
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	_, ipv6Subnet, _ := net.ParseCIDR(ipv6TestOptions.IPv6Subnet)
+
+	for _, containerName := range testContainerNames {
+		if ok := runTestContainer(containerName, testNetworkName, ""); !ok {
+			return false
+		}
+
+		ipAddress, err := getContainerIPAddress(containerName, testNetworkName)
+		if err != nil || ipAddress == "" {
+			printError(fmt.Sprintf("IPv6: Container %s was not assigned an IPv4 address on the dual-stack Docker network!", containerName))
+			passed = false
+		} else {
+			printSuccess(fmt.Sprintf("IPv6: Container %s was assigned the IPv4 address %s on the dual-stack Docker network", containerName, ipAddress))
+		}
+
+		ipv6Address, err := getContainerNetworkSetting(containerName, testNetworkName, "GlobalIPv6Address")
+		if err != nil || ipv6Address == "" {
+			printError(fmt.Sprintf("IPv6: Container %s was not assigned an IPv6 address on the Docker network using plugin %s!", containerName, pluginName))
+			return false
+		}
+
+		if ip := net.ParseIP(ipv6Address); ip == nil || (ipv6Subnet != nil && !ipv6Subnet.Contains(ip)) {
+			printError(fmt.Sprintf("IPv6: Container %s was assigned the IPv6 address %s which is outside of the subnet %s!", containerName, ipv6Address, ipv6TestOptions.IPv6Subnet))
+			passed = false
+		}
+
+		interfaceName, err := getContainerInterfaceName(containerName, ipv6Address)
+		if err != nil || interfaceName == "" {
+			printError(fmt.Sprintf("IPv6: Container %s does not have an interface with the IPv6 address %s!", containerName, ipv6Address))
+			return false
+		}
+		printSuccess(fmt.Sprintf("IPv6: Container %s has interface %s with IPv6 address %s", containerName, interfaceName, ipv6Address))
+
+		containerIPv6Gateway, _ := getContainerNetworkSetting(containerName, testNetworkName, "IPv6Gateway")
+		if containerIPv6Gateway != ipv6Gateway {
+			printError(fmt.Sprintf("IPv6: Container %s was assigned the IPv6 gateway %s instead of %s!", containerName, containerIPv6Gateway, ipv6Gateway))
+			passed = false
+		} else {
+			printSuccess(fmt.Sprintf("IPv6: Container %s was assigned the IPv6 gateway %s", containerName, containerIPv6Gateway))
+		}
+
+		ipv6Addresses[containerName] = ipv6Address
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Check the IPv6 gateway and the other test containers can be reached over IPv6
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for _, sourceContainer := range testContainerNames {
+		if ipv6Gateway != "" {
+			output, err := runCommand("docker exec " + sourceContainer + " ping -6 -c 3 -W 2 " + ipv6Gateway)
+			if err != nil {
+				printWarning(fmt.Sprintf("IPv6: Container %s is unable to reach the IPv6 gateway %s over ICMPv6! %s", sourceContainer, ipv6Gateway, output))
+			} else {
+				printSuccess(fmt.Sprintf("IPv6: Container %s reached the IPv6 gateway %s over ICMPv6", sourceContainer, ipv6Gateway))
+			}
+		}
+
+		for _, targetContainer := range testContainerNames {
+			if sourceContainer == targetContainer {
+				continue
+			}
+			targetIPv6Address := ipv6Addresses[targetContainer]
+
+			output, err := runCommand("docker exec " + sourceContainer + " ping -6 -c 3 -W 2 " + targetIPv6Address)
+			if err != nil {
+				printError(fmt.Sprintf("IPv6: Container %s is unable to reach container %s (%s) over ICMPv6!, %s", sourceContainer, targetContainer, targetIPv6Address, output))
+				passed = false
+			} else {
+				printSuccess(fmt.Sprintf("IPv6: Container %s reached container %s (%s) over ICMPv6", sourceContainer, targetContainer, targetIPv6Address))
+			}
+
+			output, err = runCommand("docker exec " + sourceContainer + " wget -q -O - -T 5 http://[" + targetIPv6Address + "]:" + testContainerTCPPort + "/")
+			if err != nil || output != "ok" {
+				printError(fmt.Sprintf("IPv6: Container %s is unable to reach container %s (%s) over TCP port %s!, %s", sourceContainer, targetContainer, targetIPv6Address, testContainerTCPPort, output))
+				passed = false
+			} else {
+				printSuccess(fmt.Sprintf("IPv6: Container %s reached container %s (%s) over TCP port %s", sourceContainer, targetContainer, targetIPv6Address, testContainerTCPPort))
+			}
+		}
+	}
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the names of the network interfaces inside the container
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getContainerInterfaces(containerName string) (map[string]bool, error) {
+	var interfaces = map[string]bool{}
+
+	output, err := runCommand("docker exec " + containerName + " ls /sys/class/net")
+	if err != nil {
+		return nil, errors.New(err.Error() + ", " + output)
+	}
+
+	for _, interfaceName := range strings.Fields(output) {
+		interfaces[interfaceName] = true
+	}
+
+	return interfaces, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Connects and disconnects a running container to the Docker test network and verifies the interface appears and disappears inside the container
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testNetworkConnectDisconnect(pluginName string) bool {
+	var containerName = testContainerNames[0]
+
+	if ok := createDockerNetwork(pluginName, testNetworkOptions); !ok {
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Remove the test container and the test network when the test completes
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	defer func() {
+		removeTestContainer(containerName)
+		removeDockerNetwork(pluginName)
+	}()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Start the test container on the default bridge network and get its interfaces
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if ok := runTestContainer(containerName, "bridge", ""); !ok {
+		return false
+	}
+
+	interfacesBefore, err := getContainerInterfaces(containerName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to list the interfaces of container %s! %s", containerName, err.Error()))
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Connect the running container to the test network (CreateEndpoint and Join)
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	output, err := runCommand("docker network connect " + testNetworkName + " " + containerName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to connect the running container %s to the Docker network using plugin %s!, %s", containerName, pluginName, output))
+		return false
+	}
+	printSuccess(fmt.Sprintf("Running container %s was connected to the Docker network using plugin %s", containerName, pluginName))
+
+	ipAddress, err := getContainerIPAddress(containerName, testNetworkName)
+	if err != nil || ipAddress == "" {
+		printError(fmt.Sprintf("Container %s was not assigned an IP address when connected to the Docker network using plugin %s!", containerName, pluginName))
+		return false
+	}
+
+	interfaceName, err := getContainerInterfaceName(containerName, ipAddress)
+	if err != nil || interfaceName == "" || interfacesBefore[interfaceName] {
+		printError(fmt.Sprintf("A new interface with the IP address %s did not appear in container %s after connecting it to the Docker network!", ipAddress, containerName))
+		return false
+	}
+	printSuccess(fmt.Sprintf("Interface %s with IP address %s appeared in container %s after connecting it to the Docker network", interfaceName, ipAddress, containerName))
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Disconnect the running container from the test network (Leave and DeleteEndpoint)
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	output, err = runCommand("docker network disconnect " + testNetworkName + " " + containerName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to disconnect the running container %s from the Docker network using plugin %s!, %s", containerName, pluginName, output))
+		return false
+	}
+	printSuccess(fmt.Sprintf("Running container %s was disconnected from the Docker network using plugin %s", containerName, pluginName))
+
+	interfacesAfter, err := getContainerInterfaces(containerName)
+	if err != nil {
+		printError(fmt.Sprintf("Unable to list the interfaces of container %s! %s", containerName, err.Error()))
+		return false
+	}
+
+	if interfacesAfter[interfaceName] {
+		printError(fmt.Sprintf("Interface %s is still present in container %s after disconnecting it from the Docker network!", interfaceName, containerName))
+		return false
+	}
+	printSuccess(fmt.Sprintf("Interface %s disappeared from container %s after disconnecting it from the Docker network", interfaceName, containerName))
+
+	return true
 }
@@ -0,0 +1,73 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Test suite for plugins implementing the IPAM driver interface.
+//
+// The test networks use the built-in bridge driver with the plugin as their IPAM driver, so the address pools and the
+// container addresses are allocated by the plugin.
+//
+
+package main
+
+import (
+	"fmt"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the network options of an IPAM plugin test network. Driver options are meant for a network driver plugin and are not passed to the bridge driver.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getIPAMPluginNetworkOptions(pluginName string, networkOptions dockerNetworkOptionsStruct) dockerNetworkOptionsStruct {
+	networkOptions.Driver = "bridge"
+	networkOptions.IPAMDriver = pluginName
+	networkOptions.DriverOptions = nil
+
+	return networkOptions
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Creates a Docker test network without a subnet and verifies the plugin allocated an address pool and the container addresses
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testIPAMPluginPoolAllocation(pluginName string) bool {
+	var passed = true
+
+	if ok := createDockerNetwork(pluginName, getIPAMPluginNetworkOptions(pluginName, testNetworkOptions)); !ok {
+		return false
+	}
+
+	ipamConfigs, err := getDockerNetworkIPAMConfig()
+	if err != nil {
+		printError("Unable to inspect the IPAM configuration of the Docker network! " + err.Error())
+		passed = false
+	} else if len(ipamConfigs) == 0 || ipamConfigs[0].Subnet == "" {
+		printError(fmt.Sprintf("The IPAM plugin %s did not allocate an address pool for the Docker network!", pluginName))
+		passed = false
+	} else {
+		printSuccess(fmt.Sprintf("The IPAM plugin %s allocated the address pool %s for the Docker network", pluginName, ipamConfigs[0].Subnet))
+	}
+
+	if passed {
+		passed = testContainerConnectivity(pluginName)
+	}
+
+	if ok := removeDockerNetwork(pluginName); !ok {
+		passed = false
+	}
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Test the IPAM Plugin
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runIPAMPluginTest(pluginName string) {
+	printStep("Testing the address pool allocation of IPAM plugin: " + pluginName + " ...")
+
+	if ok := testIPAMPluginPoolAllocation(pluginName); !ok {
+		printError("Docker Network Plugin Test has failed! Unable to allocate addresses for a Docker network using the IPAM plugin: " + pluginName)
+	}
+
+	printStep("Testing the requested IPAM settings using IPAM plugin: " + pluginName + " ...")
+
+	if ok := testNetworkIPAM(pluginName, getIPAMPluginNetworkOptions(pluginName, ipamTestOptions)); !ok {
+		printError("Docker Network Plugin Test has failed! The IPAM settings were not honored by the IPAM plugin: " + pluginName)
+	}
+}
@@ -0,0 +1,496 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// This program is a reference Docker network driver plugin used to self-test the inspectDockerNetworkingPlugin checks.
+//
+// It implements the docker.networkdriver/1.0 remote API over a unix socket. By default it behaves correctly: every network is
+// backed by a Linux bridge and every endpoint by a veth pair, so containers attached to a network can reach each other.
+//
+// It can also inject a fault into one API method so you can prove the inspectDockerNetworkingPlugin checks detect it.
+//
+// Syntax: ./mock_network_driver [options]
+//
+//   --socket         (The unix socket to listen on. Defaults to /run/docker/plugins/mocknet.sock)
+//   --scope          (The scope returned by NetworkDriver.GetCapabilities: local or global. Defaults to local)
+//   --fault          (The fault to inject: none, error, bad-json, bad-response, hang or crash. Defaults to none)
+//   --fault-method   (The API method the fault is injected into. Defaults to NetworkDriver.CreateEndpoint)
+//   --hang-duration  (How long the hang fault blocks the response. Defaults to 10m)
+//   --debug          (write debugging information)
+//   --help           (display help)
+//
+// Pre-requisites:
+//
+//     Must be run as root on Linux with the ip command (iproute2) installed.
+//
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const pluginContentType = "application/vnd.docker.plugins.v1.2+json"
+
+var debugMode bool
+var driverScope string
+var fault string
+var faultMethod string
+var hangDuration time.Duration
+
+var networks = map[string]*mockNetworkStruct{}
+var endpoints = map[string]*mockEndpointStruct{}
+var stateMutex sync.Mutex
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a network created by the driver
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type mockNetworkStruct struct {
+	BridgeName  string
+	Gateway     string
+	GatewayIPv6 string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines an endpoint created by the driver
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type mockEndpointStruct struct {
+	NetworkID     string
+	Address       string
+	HostVethName  string
+	ContainerName string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the fields of the remote API requests used by the driver
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type requestStruct struct {
+	NetworkID  string `json:"NetworkID"`
+	EndpointID string `json:"EndpointID"`
+	IPv4Data   []struct {
+		Gateway string `json:"Gateway"`
+	} `json:"IPv4Data"`
+	IPv6Data []struct {
+		Gateway string `json:"Gateway"`
+	} `json:"IPv6Data"`
+	Interface *struct {
+		Address string `json:"Address"`
+	} `json:"Interface"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Runs the ip command
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runIP(args ...string) error {
+	if debugMode {
+		log.Println("ip " + strings.Join(args, " "))
+	}
+
+	output, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ip %s failed: %s %s", strings.Join(args, " "), err.Error(), strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Strips the prefix length from an address in CIDR notation
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func stripPrefixLength(address string) string {
+	return strings.Split(address, "/")[0]
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles Plugin.Activate
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func activate(request requestStruct) (interface{}, error) {
+	return map[string]interface{}{"Implements": []string{"NetworkDriver"}}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.GetCapabilities
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getCapabilities(request requestStruct) (interface{}, error) {
+	return map[string]interface{}{"Scope": driverScope, "ConnectivityScope": driverScope}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.CreateNetwork by creating a bridge holding the gateway addresses
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func createNetwork(request requestStruct) (interface{}, error) {
+	if len(request.NetworkID) < 10 {
+		return nil, fmt.Errorf("invalid NetworkID %q", request.NetworkID)
+	}
+
+	network := &mockNetworkStruct{BridgeName: "mock-" + request.NetworkID[:10]}
+	if err := runIP("link", "add", "name", network.BridgeName, "type", "bridge"); err != nil {
+		return nil, err
+	}
+
+	for _, ipv4Data := range request.IPv4Data {
+		if ipv4Data.Gateway != "" {
+			network.Gateway = ipv4Data.Gateway
+			runIP("addr", "add", ipv4Data.Gateway, "dev", network.BridgeName)
+		}
+	}
+	for _, ipv6Data := range request.IPv6Data {
+		if ipv6Data.Gateway != "" {
+			network.GatewayIPv6 = ipv6Data.Gateway
+			runIP("addr", "add", ipv6Data.Gateway, "dev", network.BridgeName)
+		}
+	}
+
+	if err := runIP("link", "set", network.BridgeName, "up"); err != nil {
+		runIP("link", "del", network.BridgeName)
+		return nil, err
+	}
+
+	stateMutex.Lock()
+	networks[request.NetworkID] = network
+	stateMutex.Unlock()
+
+	return map[string]interface{}{}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.DeleteNetwork by deleting the bridge
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func deleteNetwork(request requestStruct) (interface{}, error) {
+	stateMutex.Lock()
+	network, found := networks[request.NetworkID]
+	delete(networks, request.NetworkID)
+	stateMutex.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf("network %s not found", request.NetworkID)
+	}
+
+	if err := runIP("link", "del", network.BridgeName); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.CreateEndpoint. The address assigned by Docker's IPAM is used as is, so nothing is returned in the Interface.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func createEndpoint(request requestStruct) (interface{}, error) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	if _, found := networks[request.NetworkID]; !found {
+		return nil, fmt.Errorf("network %s not found", request.NetworkID)
+	}
+	if len(request.EndpointID) < 7 {
+		return nil, fmt.Errorf("invalid EndpointID %q", request.EndpointID)
+	}
+
+	endpoint := &mockEndpointStruct{
+		NetworkID:     request.NetworkID,
+		HostVethName:  "vmh" + request.EndpointID[:7],
+		ContainerName: "vmc" + request.EndpointID[:7],
+	}
+	if request.Interface != nil {
+		endpoint.Address = request.Interface.Address
+	}
+	endpoints[request.EndpointID] = endpoint
+
+	return map[string]interface{}{}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.DeleteEndpoint
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func deleteEndpoint(request requestStruct) (interface{}, error) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	if _, found := endpoints[request.EndpointID]; !found {
+		return nil, fmt.Errorf("endpoint %s not found", request.EndpointID)
+	}
+	delete(endpoints, request.EndpointID)
+
+	return map[string]interface{}{}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.Join by creating a veth pair. The host end is attached to the network's bridge and the other end is
+// returned to Docker, which moves it into the container's sandbox.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func join(request requestStruct) (interface{}, error) {
+	stateMutex.Lock()
+	endpoint, endpointFound := endpoints[request.EndpointID]
+	network, networkFound := networks[request.NetworkID]
+	stateMutex.Unlock()
+
+	if !endpointFound || !networkFound {
+		return nil, fmt.Errorf("endpoint %s on network %s not found", request.EndpointID, request.NetworkID)
+	}
+
+	if err := runIP("link", "add", endpoint.HostVethName, "type", "veth", "peer", "name", endpoint.ContainerName); err != nil {
+		return nil, err
+	}
+	if err := runIP("link", "set", endpoint.HostVethName, "master", network.BridgeName); err != nil {
+		runIP("link", "del", endpoint.HostVethName)
+		return nil, err
+	}
+	if err := runIP("link", "set", endpoint.HostVethName, "up"); err != nil {
+		runIP("link", "del", endpoint.HostVethName)
+		return nil, err
+	}
+
+	response := map[string]interface{}{
+		"InterfaceName": map[string]string{"SrcName": endpoint.ContainerName, "DstPrefix": "eth"},
+	}
+	if network.Gateway != "" {
+		response["Gateway"] = stripPrefixLength(network.Gateway)
+	}
+	if network.GatewayIPv6 != "" {
+		response["GatewayIPv6"] = stripPrefixLength(network.GatewayIPv6)
+	}
+
+	return response, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles NetworkDriver.Leave by deleting the veth pair
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func leave(request requestStruct) (interface{}, error) {
+	stateMutex.Lock()
+	endpoint, found := endpoints[request.EndpointID]
+	stateMutex.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf("endpoint %s not found", request.EndpointID)
+	}
+
+	if err := runIP("link", "del", endpoint.HostVethName); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Handles the optional API methods the driver does not need to implement
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func noOperation(request requestStruct) (interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+var handlers = map[string]func(requestStruct) (interface{}, error){
+	"Plugin.Activate":                           activate,
+	"NetworkDriver.GetCapabilities":             getCapabilities,
+	"NetworkDriver.CreateNetwork":               createNetwork,
+	"NetworkDriver.DeleteNetwork":               deleteNetwork,
+	"NetworkDriver.CreateEndpoint":              createEndpoint,
+	"NetworkDriver.DeleteEndpoint":              deleteEndpoint,
+	"NetworkDriver.EndpointOperInfo":            noOperation,
+	"NetworkDriver.Join":                        join,
+	"NetworkDriver.Leave":                       leave,
+	"NetworkDriver.AllocateNetwork":             noOperation,
+	"NetworkDriver.FreeNetwork":                 noOperation,
+	"NetworkDriver.DiscoverNew":                 noOperation,
+	"NetworkDriver.DiscoverDelete":              noOperation,
+	"NetworkDriver.ProgramExternalConnectivity": noOperation,
+	"NetworkDriver.RevokeExternalConnectivity":  noOperation,
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a response for the method which does not conform to the remote driver specification
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func badResponse(method string) interface{} {
+	switch method {
+	case "Plugin.Activate":
+		return map[string]interface{}{"Implements": []string{"VolumeDriver"}}
+	case "NetworkDriver.GetCapabilities":
+		return map[string]interface{}{"Scope": "everywhere"}
+	case "NetworkDriver.CreateEndpoint":
+		return map[string]interface{}{"Interface": map[string]string{"Address": "192.0.2.1/24", "MacAddress": "not-a-mac"}}
+	case "NetworkDriver.Join":
+		return map[string]interface{}{"InterfaceName": map[string]string{}, "Gateway": "not-an-ip"}
+	}
+
+	return map[string]interface{}{"Unexpected": true}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Writes a JSON response
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func writeResponse(rw http.ResponseWriter, response interface{}) {
+	rw.Header().Set("Content-Type", pluginContentType)
+	json.NewEncoder(rw).Encode(response)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Process the HTTP Request
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func processRequest(rw http.ResponseWriter, req *http.Request) {
+	method := strings.TrimPrefix(req.URL.Path, "/")
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if debugMode {
+		log.Println(fmt.Sprintf(`%s request received: %s`, method, string(body)))
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Inject the fault if this is the faulty method
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if method == faultMethod {
+		switch fault {
+		case `error`:
+			writeResponse(rw, map[string]string{"Err": "mock_network_driver: injected " + method + " failure"})
+			return
+		case `bad-json`:
+			rw.Header().Set("Content-Type", pluginContentType)
+			fmt.Fprint(rw, `{"Err": "", "Interface": {`)
+			return
+		case `bad-response`:
+			writeResponse(rw, badResponse(method))
+			return
+		case `hang`:
+			time.Sleep(hangDuration)
+		case `crash`:
+			log.Println(`Crashing on ` + method)
+			os.Exit(2)
+		}
+	}
+
+	handler, found := handlers[method]
+	if !found {
+		rw.Header().Set("Content-Type", pluginContentType)
+		rw.WriteHeader(http.StatusNotFound)
+		writeResponse(rw, map[string]string{"Err": "unknown method " + method})
+		return
+	}
+
+	var request requestStruct
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &request); err != nil {
+			rw.Header().Set("Content-Type", pluginContentType)
+			rw.WriteHeader(http.StatusBadRequest)
+			writeResponse(rw, map[string]string{"Err": "invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := handler(request)
+	if err != nil {
+		log.Println(err)
+		writeResponse(rw, map[string]string{"Err": err.Error()})
+		return
+	}
+
+	writeResponse(rw, response)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Display the Command Help
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func usage() {
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Runs a reference Docker network driver plugin which can inject faults for testing inspectDockerNetworkingPlugin`)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Syntax: mock_network_driver [options]`)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Options:`)
+	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr)
+}
+
+func main() {
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Set the Log Flags to display the short file name and line number when Logging error messages using the log Package
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Setup, parse and verify the command line options
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	socketPtr := flag.String(`socket`, `/run/docker/plugins/mocknet.sock`, ` The unix socket for the mock_network_driver to listen on.`)
+	scopePtr := flag.String(`scope`, `local`, ` The scope returned by NetworkDriver.GetCapabilities: local or global.`)
+	faultPtr := flag.String(`fault`, `none`, ` The fault to inject: none, error, bad-json, bad-response, hang or crash.`)
+	faultMethodPtr := flag.String(`fault-method`, `NetworkDriver.CreateEndpoint`, ` The API method the fault is injected into.`)
+	hangDurationPtr := flag.Duration(`hang-duration`, 10*time.Minute, ` How long the hang fault blocks the response.`)
+	debugPtr := flag.Bool(`debug`, false, ` Enables debugging output.`)
+	helpPtr := flag.Bool(`help`, false, ` Displays the command help.`)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Display the command usage if the help command line option was specified
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if *helpPtr {
+		usage()
+		os.Exit(0)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Get the command line options
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	debugMode = *debugPtr
+	driverScope = *scopePtr
+	fault = *faultPtr
+	faultMethod = *faultMethodPtr
+	hangDuration = *hangDurationPtr
+
+	switch fault {
+	case `none`, `error`, `bad-json`, `bad-response`, `hang`, `crash`:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown fault %s!\n", fault)
+		usage()
+		os.Exit(1)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Listen on the unix socket, replacing a stale socket left behind by a previous run
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if err := os.MkdirAll(filepath.Dir(*socketPtr), 0755); err != nil {
+		log.Fatal(err)
+	}
+	os.Remove(*socketPtr)
+
+	listener, err := net.Listen(`unix`, *socketPtr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Remove the unix socket when the program is terminated
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+		os.Remove(*socketPtr)
+		os.Exit(0)
+	}()
+
+	if debugMode {
+		log.Println(fmt.Sprintf(`Listening on socket: %s with fault: %s in %s`, *socketPtr, fault, faultMethod))
+	}
+
+	log.Fatal(http.Serve(listener, http.HandlerFunc(processRequest)))
+}
@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+
+#######################################################################################################################################
+# This bash script proves the inspectDockerNetworkingPlugin checks detect every fault the mock_network_driver can inject
+#
+# For each fault mode the mock_network_driver managed plugin is created from a plugin directory with the fault in its args,
+# inspected with the protocol test, and the output is checked for the error the fault must cause.
+#
+# The script accepts 1 optional command line parameter:  Path of the inspectDockerNetworkingPlugin binary
+#                                                         (defaults to ../inspectDockerNetworkingPlugin)
+#
+# Must be run as root on a Linux Docker host from the mock_network_driver directory.
+#
+#######################################################################################################################################
+
+INSPECT_BINARY=${1:-../inspectDockerNetworkingPlugin}
+if [[ ! -x $INSPECT_BINARY ]]; then
+   printf 'Unable to find the inspectDockerNetworkingPlugin binary '"${INSPECT_BINARY}"'! Build it with go build first.\n'
+   exit 1
+fi
+
+PLUGIN_NAME='mocknet-self-test:latest'
+WORK_DIRECTORY=$(mktemp -d /tmp/mock_network_driver_self_test.XXXXXX)
+trap 'rm -rf "${WORK_DIRECTORY}"' EXIT
+
+#######################################################################################################################################
+# Build the root filesystem of the mock_network_driver managed plugin
+#######################################################################################################################################
+docker build -q -t mock_network_driver_rootfs . > /dev/null || exit 1
+mkdir -p "${WORK_DIRECTORY}/rootfs"
+CONTAINER_ID=$(docker create mock_network_driver_rootfs) || exit 1
+docker export "${CONTAINER_ID}" | tar -x -C "${WORK_DIRECTORY}/rootfs"
+docker rm "${CONTAINER_ID}" > /dev/null
+
+#######################################################################################################################################
+# Each test case is: fault | fault method | expected exit status | text the output must contain
+#######################################################################################################################################
+TEST_CASES=(
+   'none|NetworkDriver.CreateEndpoint|0|NetworkDriver.CreateEndpoint conforms to the remote driver specification'
+   'error|NetworkDriver.CreateEndpoint|1|NetworkDriver.CreateEndpoint failed! the driver returned an error'
+   'error|NetworkDriver.Join|1|NetworkDriver.Join failed! the driver returned an error'
+   'bad-json|NetworkDriver.CreateEndpoint|1|NetworkDriver.CreateEndpoint failed! the response is not valid JSON'
+   'bad-response|NetworkDriver.GetCapabilities|1|NetworkDriver.GetCapabilities failed! the Scope must be local or global'
+   'bad-response|NetworkDriver.CreateEndpoint|1|NetworkDriver.CreateEndpoint failed! the driver returned the address'
+   'bad-response|NetworkDriver.Join|1|NetworkDriver.Join failed! the response must contain InterfaceName'
+   'hang|NetworkDriver.CreateEndpoint|1|Client.Timeout exceeded'
+   'crash|NetworkDriver.CreateEndpoint|1|NetworkDriver.CreateEndpoint failed!'
+)
+
+FAILED=0
+for TEST_CASE in "${TEST_CASES[@]}"; do
+   IFS='|' read -r FAULT FAULT_METHOD EXPECTED_STATUS EXPECTED_OUTPUT <<< "${TEST_CASE}"
+
+   ####################################################################################################################################
+   # Set the fault in the plugin args. The hang outlasts the 30 second timeout of the protocol test.
+   ####################################################################################################################################
+   sed 's|"value": \[\]|"value": ["--fault", "'"${FAULT}"'", "--fault-method", "'"${FAULT_METHOD}"'", "--hang-duration", "45s"]|' \
+      plugin/config.json > "${WORK_DIRECTORY}/config.json"
+
+   OUTPUT=$("${INSPECT_BINARY}" --plugin-dir "${WORK_DIRECTORY}" --protocol-test "${PLUGIN_NAME}" 2>&1)
+   STATUS=$?
+   docker plugin remove --force "${PLUGIN_NAME}" > /dev/null 2>&1
+
+   if [[ $STATUS -ne $EXPECTED_STATUS ]] || [[ $OUTPUT != *"${EXPECTED_OUTPUT}"* ]]; then
+      printf 'FAIL  %-13s %-32s exit status %d, expected %d and the output to contain: %s\n' "${FAULT}" "${FAULT_METHOD}" "${STATUS}" "${EXPECTED_STATUS}" "${EXPECTED_OUTPUT}"
+      FAILED=1
+   else
+      printf 'PASS  %-13s %-32s %s\n' "${FAULT}" "${FAULT_METHOD}" "${EXPECTED_OUTPUT}"
+   fi
+done
+
+exit $FAILED
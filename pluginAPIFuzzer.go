@@ -0,0 +1,265 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Fuzzer for the remote network driver API of the Docker Networking Plugin.
+//
+// Malformed, oversized, truncated and type-confused JSON payloads are sent to every API method of the plugin's socket.
+// Crashes, hangs, non-JSON responses and plugin restarts are reported as errors with the payload which caused them.
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const fuzzTimeout = 10 * time.Second
+const fuzzRestartWait = 15 * time.Second
+const fuzzOversizedLength = 8 * 1024 * 1024
+const fuzzNestingDepth = 10000
+const fuzzReportedPayloadLength = 256
+
+var fuzzEnabled = false
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a payload sent by the fuzzer
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type fuzzPayloadStruct struct {
+	Description string
+	Body        []byte
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a valid request for each remote network driver API method. The fuzz payloads are derived from these requests.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getFuzzRequestTemplates() map[string]interface{} {
+	networkID := generateRandomID()
+	endpointID := generateRandomID()
+	ipv4Data, endpointAddress := getProtocolTestIPv4Data()
+
+	return map[string]interface{}{
+		"Plugin.Activate":               struct{}{},
+		"NetworkDriver.GetCapabilities": struct{}{},
+		"NetworkDriver.CreateNetwork": remoteDriverCreateNetworkRequestStruct{
+			NetworkID: networkID,
+			Options:   getProtocolTestNetworkOptions(),
+			IPv4Data:  ipv4Data,
+			IPv6Data:  []remoteDriverIPAMDataStruct{},
+		},
+		"NetworkDriver.DeleteNetwork": remoteDriverNetworkRequestStruct{NetworkID: networkID},
+		"NetworkDriver.CreateEndpoint": remoteDriverCreateEndpointRequestStruct{
+			NetworkID:  networkID,
+			EndpointID: endpointID,
+			Interface:  &remoteDriverEndpointInterfaceStruct{Address: endpointAddress},
+			Options:    map[string]interface{}{},
+		},
+		"NetworkDriver.DeleteEndpoint":   remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID},
+		"NetworkDriver.EndpointOperInfo": remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID},
+		"NetworkDriver.Join": remoteDriverJoinRequestStruct{
+			NetworkID:  networkID,
+			EndpointID: endpointID,
+			SandboxKey: "/var/run/docker/netns/" + endpointID[:12],
+			Options:    map[string]interface{}{},
+		},
+		"NetworkDriver.Leave":                       remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID},
+		"NetworkDriver.ProgramExternalConnectivity": remoteDriverJoinRequestStruct{NetworkID: networkID, EndpointID: endpointID, Options: map[string]interface{}{}},
+		"NetworkDriver.RevokeExternalConnectivity":  remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID},
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a value of a different JSON type than the passed value
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getTypeConfusedValue(value interface{}) interface{} {
+	switch value.(type) {
+	case string:
+		return 12345
+	case float64:
+		return "12345"
+	case bool:
+		return "true"
+	case map[string]interface{}:
+		return []interface{}{"confused"}
+	case []interface{}:
+		return map[string]interface{}{"confused": true}
+	}
+
+	return map[string]interface{}{"confused": nil}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the fuzz payloads derived from a valid request
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getFuzzPayloads(requestTemplate interface{}) []fuzzPayloadStruct {
+	validBody, _ := json.Marshal(requestTemplate)
+
+	var payloads = []fuzzPayloadStruct{
+		{"empty", []byte(``)},
+		{"malformed", []byte(`{"NetworkID": "` + generateRandomID() + `", `)},
+		{"not JSON", []byte(`this is not JSON`)},
+		{"invalid UTF-8", []byte("{\"NetworkID\": \"\xff\xfe\xfd\"}")},
+		{"null", []byte(`null`)},
+		{"wrong top level type", []byte(`["NetworkID", 1, true]`)},
+		{"truncated", validBody[:len(validBody)/2]},
+		{"deeply nested", []byte(strings.Repeat(`{"a":`, fuzzNestingDepth) + `1` + strings.Repeat(`}`, fuzzNestingDepth))},
+		{"oversized", []byte(`{"NetworkID": "` + strings.Repeat("A", fuzzOversizedLength) + `", "EndpointID": "` + strings.Repeat("B", 1024) + `"}`)},
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Replace each field of the valid request with a value of the wrong type
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	var fields map[string]interface{}
+	if err := json.Unmarshal(validBody, &fields); err == nil {
+		var fieldNames []string
+		for fieldName := range fields {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+
+		for _, fieldName := range fieldNames {
+			confusedFields := map[string]interface{}{}
+			for name, value := range fields {
+				confusedFields[name] = value
+			}
+			confusedFields[fieldName] = getTypeConfusedValue(fields[fieldName])
+
+			confusedBody, _ := json.Marshal(confusedFields)
+			payloads = append(payloads, fuzzPayloadStruct{"type-confused " + fieldName, confusedBody})
+		}
+	}
+
+	return payloads
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the payload formatted for the report. Large payloads are truncated.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatFuzzPayload(payload []byte) string {
+	if len(payload) > fuzzReportedPayloadLength {
+		return fmt.Sprintf("%q... (%d bytes)", payload[:fuzzReportedPayloadLength], len(payload))
+	}
+
+	return fmt.Sprintf("%q", payload)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns true if the plugin answers Plugin.Activate on its socket
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func pluginResponding(socketPath string) bool {
+	client := newPluginAPIClient(socketPath, fuzzTimeout)
+	return callPluginAPI(client, "Plugin.Activate", struct{}{}, nil) == nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Waits for the plugin to answer on its socket again after a crash. Returns false if the plugin did not come back.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func waitForPlugin(socketPath string) bool {
+	deadline := time.Now().Add(fuzzRestartWait)
+	for time.Now().Before(deadline) {
+		if pluginResponding(socketPath) {
+			return true
+		}
+		time.Sleep(1 * time.Second)
+	}
+
+	return false
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes whatever a lenient plugin created for the IDs of the request templates. The errors are ignored since the plugin
+// normally rejected the payloads and never created the network, the endpoint or the join.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func cleanupFuzzRequestTemplates(client *http.Client, requestTemplates map[string]interface{}) {
+	for _, method := range []string{"NetworkDriver.Leave", "NetworkDriver.DeleteEndpoint", "NetworkDriver.DeleteNetwork"} {
+		callPluginAPI(client, method, requestTemplates[method], nil)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Sends malformed payloads to every API method of the plugin and reports crashes, hangs, non-JSON responses and restarts
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runPluginAPIFuzzer(pluginName string) bool {
+	var findings int
+	var payloadsSent int
+
+	socketPath, err := getPluginSocketPath(pluginName)
+	if err != nil {
+		printError("Unable to find the socket of the Docker Networking Plugin! " + err.Error())
+		return false
+	}
+
+	socketInfo, err := os.Stat(socketPath)
+	if err != nil || !pluginResponding(socketPath) {
+		printError(fmt.Sprintf("The Docker Networking Plugin is not responding on its socket %s! Unable to fuzz the plugin.", socketPath))
+		return false
+	}
+
+	client := newPluginAPIClient(socketPath, fuzzTimeout)
+	requestTemplates := getFuzzRequestTemplates()
+	defer cleanupFuzzRequestTemplates(client, requestTemplates)
+
+	var methods []string
+	for method := range requestTemplates {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		for _, payload := range getFuzzPayloads(requestTemplates[method]) {
+			payloadsSent++
+			finding := ""
+
+			statusCode, responseBody, err := postPluginAPI(client, method, payload.Body)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					finding = fmt.Sprintf("hung (no response within %s)", fuzzTimeout)
+				} else {
+					finding = "crashed or dropped the connection (" + err.Error() + ")"
+				}
+			} else if !json.Valid(responseBody) {
+				finding = fmt.Sprintf("returned a non-JSON response (HTTP status %d): %s", statusCode, formatFuzzPayload(responseBody))
+			}
+
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			// A new socket means the plugin was restarted
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			newSocketInfo, statErr := os.Stat(socketPath)
+			if statErr == nil && !os.SameFile(socketInfo, newSocketInfo) {
+				finding = strings.TrimPrefix(finding+" and restarted the plugin", " and ")
+				socketInfo = newSocketInfo
+			}
+
+			if finding == "" {
+				continue
+			}
+
+			findings++
+			printError(fmt.Sprintf("Fuzz: %s %s on a %s payload: %s", method, finding, payload.Description, formatFuzzPayload(payload.Body)))
+
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			// Stop fuzzing if the plugin did not recover
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			if !waitForPlugin(socketPath) {
+				printError(fmt.Sprintf("Fuzz: the Docker Networking Plugin %s stopped responding after a %s payload was sent to %s! Fuzzing was aborted.", pluginName, payload.Description, method))
+				return false
+			}
+			if newSocketInfo, statErr := os.Stat(socketPath); statErr == nil {
+				socketInfo = newSocketInfo
+			}
+		}
+	}
+
+	if findings > 0 {
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("Fuzz: the Docker Networking Plugin %s handled %d malformed payloads sent to %d API methods without crashing, hanging or returning non-JSON responses",
+		pluginName, payloadsSent, len(methods)))
+	return true
+}
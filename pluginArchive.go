@@ -0,0 +1,248 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Offline inspection of a Docker Networking Plugin from an OCI image layout on disk.
+//
+// The plugin archive is an OCI image layout directory, or an oci-archive tarball of one (optionally gzip compressed), as
+// written by skopeo copy docker://<plugin> oci-archive:plugin.tar. The manifests, the config blob and the layers are read
+// from the archive instead of the registry and go through the same inspection. The plugin is then created from its
+// config blob and unpacked rootfs with docker plugin create, so no registry is accessed at all.
+//
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const ociLayoutFile = "oci-layout"
+const ociIndexFile = "index.json"
+const ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+var pluginArchive string
+var pluginArchiveLayoutDirectory string
+var pluginArchiveTemporaryDirectory string
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Extracts the regular files and directories of a tarball, optionally gzip compressed, into a directory
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func extractArchive(archivePath string, directory string) error {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	var reader io.Reader = bufio.NewReader(archiveFile)
+	if magic, err := reader.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean("/" + header.Name)
+		if name == "/" {
+			continue
+		}
+		hostPath := filepath.Join(directory, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(hostPath, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+				return err
+			}
+			hostFile, err := os.OpenFile(hostPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(hostFile, tarReader)
+			hostFile.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Opens the plugin archive. A tarball is extracted into a temporary directory, then the OCI image layout is verified.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func openPluginArchive(archivePath string) error {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return err
+	}
+
+	pluginArchiveTemporaryDirectory, err = ioutil.TempDir("", "inspectDockerNetworkingPlugin-archive-")
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		pluginArchiveLayoutDirectory = archivePath
+	} else {
+		pluginArchiveLayoutDirectory = filepath.Join(pluginArchiveTemporaryDirectory, "layout")
+		if err := extractArchive(archivePath, pluginArchiveLayoutDirectory); err != nil {
+			return errors.New("unable to extract the plugin archive " + archivePath + ", " + err.Error())
+		}
+	}
+
+	for _, fileName := range []string{ociLayoutFile, ociIndexFile} {
+		if _, err := os.Stat(filepath.Join(pluginArchiveLayoutDirectory, fileName)); err != nil {
+			return errors.New("the plugin archive " + archivePath + " is not an OCI image layout, it does not contain " + fileName +
+				". Export the plugin with skopeo copy docker://<plugin> oci-archive:<file>.")
+		}
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes the temporary directory of the plugin archive
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func removePluginArchive() {
+	if pluginArchiveTemporaryDirectory != "" {
+		os.RemoveAll(pluginArchiveTemporaryDirectory)
+		pluginArchiveTemporaryDirectory = ""
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Opens a blob of the OCI image layout of the plugin archive
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func openArchiveBlob(digest string) (*os.File, error) {
+	if !referenceDigestRegexp.MatchString(digest) {
+		return nil, errors.New("the digest " + digest + " is not valid")
+	}
+
+	algorithmHex := strings.SplitN(digest, ":", 2)
+	blobFile, err := os.Open(filepath.Join(pluginArchiveLayoutDirectory, "blobs", algorithmHex[0], algorithmHex[1]))
+	if os.IsNotExist(err) {
+		return nil, errors.New("the plugin archive does not contain the blob " + digest)
+	}
+
+	return blobFile, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Reads a manifest of the plugin archive by digest, or by tag from the index of the OCI image layout. Returns the raw content
+// of the manifest and its media type. An index listing several manifests and no tags is the manifest list of the plugin.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func readArchiveManifest(reference string) ([]byte, string, error) {
+	if referenceDigestRegexp.MatchString(reference) {
+		blobFile, err := openArchiveBlob(reference)
+		if err != nil {
+			return nil, "", err
+		}
+		defer blobFile.Close()
+
+		content, err := ioutil.ReadAll(blobFile)
+		return content, "", err
+	}
+
+	var index registryManifestStruct
+	content, err := ioutil.ReadFile(filepath.Join(pluginArchiveLayoutDirectory, ociIndexFile))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, "", errors.New("the index.json of the plugin archive is not valid JSON, " + err.Error())
+	}
+
+	var tags []string
+	for _, descriptor := range index.Manifests {
+		refName := descriptor.Annotations[ociRefNameAnnotation]
+		if refName == "" {
+			continue
+		}
+		if refName == reference || strings.HasSuffix(refName, ":"+reference) {
+			content, _, err := readArchiveManifest(descriptor.Digest)
+			return content, descriptor.MediaType, err
+		}
+		tags = append(tags, refName)
+	}
+
+	switch {
+	case len(tags) > 0:
+		return nil, "", fmt.Errorf("the plugin archive does not contain the tag %s, it contains %s", reference, strings.Join(tags, ", "))
+	case len(index.Manifests) == 0:
+		return nil, "", errors.New("the index.json of the plugin archive does not list any manifests")
+	case len(index.Manifests) == 1:
+		content, _, err := readArchiveManifest(index.Manifests[0].Digest)
+		return content, index.Manifests[0].MediaType, err
+	}
+
+	return content, mediaTypeOCIIndex, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Prepares a plugin directory for docker plugin create from the config blob and the unpacked rootfs. When running as root the
+// modes and owners recorded in the layers are restored, since the unpacked files do not carry them.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func preparePluginArchiveDirectory(configDigest string) (string, error) {
+	if pluginRootfsDirectory == "" {
+		return "", errors.New("the rootfs of the plugin could not be unpacked")
+	}
+
+	directory := filepath.Join(pluginArchiveTemporaryDirectory, "plugin")
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return "", err
+	}
+
+	configBlob, err := getRegistryBlob("", configDigest)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(directory, "config.json"), configBlob, 0644); err != nil {
+		return "", err
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// The owner is restored first, changing the owner clears the setuid and setgid bits
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for filePath, file := range pluginRootfsFiles {
+		if os.Geteuid() != 0 {
+			break
+		}
+		os.Lchown(getRootfsHostPath(filePath), file.UID, file.GID)
+		if file.Mode&os.ModeSymlink == 0 {
+			os.Chmod(getRootfsHostPath(filePath), file.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+		}
+	}
+	os.Chmod(pluginRootfsDirectory, 0755)
+
+	rootfsDirectory := filepath.Join(directory, "rootfs")
+	if err := os.Rename(pluginRootfsDirectory, rootfsDirectory); err != nil {
+		return "", err
+	}
+	pluginRootfsDirectory = rootfsDirectory
+
+	return directory, nil
+}
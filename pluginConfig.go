@@ -0,0 +1,174 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Full configuration of the Docker Networking Plugin.
+//
+// The dockerAPI configuration blob only decodes a handful of fields, so the raw config blob is downloaded and decoded
+// into the complete plugin configuration (https://docs.docker.com/engine/extend/config/).
+//
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var pluginConfig = pluginConfigStruct{}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a row of the plugin configuration section of the report
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginConfigInformationStruct struct {
+	Label  string
+	Values []string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// These structures define the plugin configuration
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginConfigArgsStruct struct {
+	Name        string   `json:"Name"`
+	Description string   `json:"Description"`
+	Settable    []string `json:"Settable"`
+	Value       []string `json:"Value"`
+}
+
+type pluginConfigEnvStruct struct {
+	Name        string   `json:"Name"`
+	Description string   `json:"Description"`
+	Settable    []string `json:"Settable"`
+	Value       string   `json:"Value"`
+}
+
+type pluginConfigDeviceStruct struct {
+	Name        string   `json:"Name"`
+	Description string   `json:"Description"`
+	Settable    []string `json:"Settable"`
+	Path        string   `json:"Path"`
+}
+
+type pluginConfigMountStruct struct {
+	Name        string   `json:"Name"`
+	Description string   `json:"Description"`
+	Settable    []string `json:"Settable"`
+	Source      string   `json:"Source"`
+	Destination string   `json:"Destination"`
+	Type        string   `json:"Type"`
+	Options     []string `json:"Options"`
+}
+
+type pluginConfigStruct struct {
+	Args          pluginConfigArgsStruct  `json:"Args"`
+	Description   string                  `json:"Description"`
+	DockerVersion string                  `json:"DockerVersion"`
+	Documentation string                  `json:"Documentation"`
+	Entrypoint    []string                `json:"Entrypoint"`
+	Env           []pluginConfigEnvStruct `json:"Env"`
+	Interface     struct {
+		Socket         string   `json:"Socket"`
+		Types          []string `json:"Types"`
+		ProtocolScheme string   `json:"ProtocolScheme"`
+	} `json:"Interface"`
+	IpcHost bool `json:"IpcHost"`
+	Linux   struct {
+		AllowAllDevices bool                       `json:"AllowAllDevices"`
+		Capabilities    []string                   `json:"Capabilities"`
+		Devices         []pluginConfigDeviceStruct `json:"Devices"`
+	} `json:"Linux"`
+	Mounts  []pluginConfigMountStruct `json:"Mounts"`
+	Network struct {
+		Type string `json:"Type"`
+	} `json:"Network"`
+	PidHost         bool   `json:"PidHost"`
+	PropagatedMount string `json:"PropagatedMount"`
+	User            struct {
+		UID uint32 `json:"UID"`
+		GID uint32 `json:"GID"`
+	} `json:"User"`
+	WorkDir string `json:"WorkDir"`
+	Rootfs  struct {
+		Type    string   `json:"type"`
+		DiffIds []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Decodes a raw plugin config blob into the full plugin configuration
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func decodePluginConfig(configBlob []byte, config *pluginConfigStruct) error {
+	if err := json.Unmarshal(configBlob, config); err != nil {
+		return errors.New("the plugin configuration is not valid JSON, " + err.Error())
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads the raw config blob of the plugin from the registry and decodes it into the full plugin configuration
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginConfig(repository string, configDigest string, config *pluginConfigStruct) error {
+	if configDigest == "" {
+		return errors.New("the plugin manifest does not reference a config blob")
+	}
+
+	configBlob, err := getRegistryBlob(repository, configDigest)
+	if err != nil {
+		return err
+	}
+
+	return decodePluginConfig(configBlob, config)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the settable fields in the form displayed in the report
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatPluginSettable(settable []string) string {
+	if len(settable) == 0 {
+		return ""
+	}
+	return " (settable: " + strings.Join(settable, ", ") + ")"
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the rows of the plugin configuration section of the report
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginConfigInformation(config pluginConfigStruct) []pluginConfigInformationStruct {
+	var env, args, mounts, devices []string
+
+	for _, envVariable := range config.Env {
+		env = append(env, envVariable.Name+"="+envVariable.Value+formatPluginSettable(envVariable.Settable))
+	}
+
+	if config.Args.Name != "" {
+		args = append(args, config.Args.Name+": "+strings.Join(config.Args.Value, " ")+formatPluginSettable(config.Args.Settable))
+	}
+
+	for _, mount := range config.Mounts {
+		mountType := mount.Type
+		if mountType == "" {
+			mountType = "bind"
+		}
+		mountDescription := fmt.Sprintf("%s -> %s (%s", getPluginSettableValue(mount.Source, mount.Name, mount.Settable, "source"), mount.Destination, mountType)
+		if len(mount.Options) > 0 {
+			mountDescription += ", " + strings.Join(mount.Options, ",")
+		}
+		mounts = append(mounts, mountDescription+")"+formatPluginSettable(mount.Settable))
+	}
+
+	for _, device := range config.Linux.Devices {
+		devices = append(devices, getPluginSettableValue(device.Path, device.Name, device.Settable, "path")+formatPluginSettable(device.Settable))
+	}
+
+	return []pluginConfigInformationStruct{
+		{"Env", env},
+		{"Args", args},
+		{"Mounts", mounts},
+		{"Capabilities", getPluginCapabilities(config)},
+		{"Devices", devices},
+		{"PropagatedMount", []string{config.PropagatedMount}},
+		{"Network Type", []string{config.Network.Type}},
+		{"Rootfs diff IDs", config.Rootfs.DiffIds},
+	}
+}
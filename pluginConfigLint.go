@@ -0,0 +1,297 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Privilege and capability lint of the Docker Networking Plugin configuration.
+//
+// The plugin is installed with --grant-all-permissions, so every privilege it requests is listed and checked against a
+// set of rules. Each finding is reported as a warning or an error together with the rationale of the rule.
+//
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const lintSeverityWarning = "warning"
+const lintSeverityError = "error"
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Capabilities a networking plugin is expected to need, and capabilities which give the plugin control of the host
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var lintAllowedCapabilities = []string{"CAP_NET_ADMIN", "CAP_NET_RAW"}
+var lintDangerousCapabilities = []string{"ALL", "CAP_SYS_ADMIN", "CAP_SYS_MODULE", "CAP_SYS_PTRACE", "CAP_SYS_RAWIO", "CAP_SYS_BOOT",
+	"CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_SETUID", "CAP_SETGID", "CAP_MKNOD", "CAP_BPF"}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a lint rule. The check returns one description per finding.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginConfigLintRuleStruct struct {
+	Name      string
+	Severity  string
+	Rationale string
+	check     func(config pluginConfigStruct) []string
+}
+
+var pluginConfigLintRules = []pluginConfigLintRuleStruct{
+	{
+		Name:      "dangerous-capability",
+		Severity:  lintSeverityError,
+		Rationale: "This capability gives the plugin control over the host well beyond what a networking plugin needs.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, capability := range getPluginCapabilities(config) {
+				if stringInSlice(capability, lintDangerousCapabilities) {
+					findings = append(findings, "the plugin requests the capability "+capability)
+				}
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "extra-capability",
+		Severity:  lintSeverityWarning,
+		Rationale: "A networking plugin should only need CAP_NET_ADMIN and CAP_NET_RAW.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, capability := range getPluginCapabilities(config) {
+				if !stringInSlice(capability, lintAllowedCapabilities) && !stringInSlice(capability, lintDangerousCapabilities) {
+					findings = append(findings, "the plugin requests the capability "+capability)
+				}
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "allow-all-devices",
+		Severity:  lintSeverityError,
+		Rationale: "AllowAllDevices gives the plugin access to every device of the host, including raw disks.",
+		check: func(config pluginConfigStruct) []string {
+			if config.Linux.AllowAllDevices {
+				return []string{"the plugin requests access to all host devices"}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "device",
+		Severity:  lintSeverityWarning,
+		Rationale: "Device nodes give the plugin direct access to host hardware.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, device := range config.Linux.Devices {
+				findings = append(findings, "the plugin requests the device "+getPluginSettableValue(device.Path, device.Name, device.Settable, "path"))
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "docker-socket-mount",
+		Severity:  lintSeverityError,
+		Rationale: "Access to the Docker socket gives the plugin full control of the Docker daemon and therefore root on the host.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, mount := range getPluginHostMounts(config) {
+				if strings.HasSuffix(path.Clean(mount.Source), "/docker.sock") {
+					findings = append(findings, "the plugin mounts the Docker socket "+mount.Source+" at "+mount.Destination)
+				}
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "host-root-mount",
+		Severity:  lintSeverityError,
+		Rationale: "Mounting the root of the host filesystem gives the plugin read and write access to every file on the host.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, mount := range getPluginHostMounts(config) {
+				if path.Clean(mount.Source) == "/" {
+					findings = append(findings, "the plugin mounts the host root filesystem at "+mount.Destination)
+				}
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "host-mount",
+		Severity:  lintSeverityWarning,
+		Rationale: "Host mounts expose host files to the plugin and should be limited to what the plugin needs.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, mount := range getPluginHostMounts(config) {
+				source := path.Clean(mount.Source)
+				if mount.Source == "" || source == "/" || strings.HasSuffix(source, "/docker.sock") {
+					continue
+				}
+				findings = append(findings, "the plugin mounts the host path "+mount.Source+" at "+mount.Destination)
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "settable-mount",
+		Severity:  lintSeverityWarning,
+		Rationale: "The host path of a settable mount is chosen at install time, review what it is set to.",
+		check: func(config pluginConfigStruct) []string {
+			var findings []string
+			for _, mount := range config.Mounts {
+				if mount.Source == "" && stringInSlice("source", mount.Settable) {
+					findings = append(findings, "the plugin has a settable host mount "+mount.Name+" at "+mount.Destination)
+				}
+			}
+			return findings
+		},
+	},
+	{
+		Name:      "pid-host",
+		Severity:  lintSeverityWarning,
+		Rationale: "Sharing the host PID namespace lets the plugin see and signal every process on the host.",
+		check: func(config pluginConfigStruct) []string {
+			if config.PidHost {
+				return []string{"the plugin runs in the host PID namespace (PidHost)"}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "ipc-host",
+		Severity:  lintSeverityWarning,
+		Rationale: "Sharing the host IPC namespace lets the plugin access the shared memory of every process on the host.",
+		check: func(config pluginConfigStruct) []string {
+			if config.IpcHost {
+				return []string{"the plugin runs in the host IPC namespace (IpcHost)"}
+			}
+			return nil
+		},
+	},
+	{
+		Name:      "host-network",
+		Severity:  lintSeverityWarning,
+		Rationale: "The host network gives the plugin access to every host interface and to services listening on localhost.",
+		check: func(config pluginConfigStruct) []string {
+			if config.Network.Type == "host" {
+				return []string{"the plugin runs in the host network namespace (Network.Type host)"}
+			}
+			return nil
+		},
+	},
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the capabilities of the plugin in their canonical CAP_ form
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginCapabilities(config pluginConfigStruct) []string {
+	var capabilities []string
+	for _, capability := range config.Linux.Capabilities {
+		capability = strings.ToUpper(strings.TrimSpace(capability))
+		if capability != "ALL" && !strings.HasPrefix(capability, "CAP_") {
+			capability = "CAP_" + capability
+		}
+		capabilities = append(capabilities, capability)
+	}
+	return capabilities
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the mounts of the plugin which bind a host path (bind is the default mount type of a plugin)
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginHostMounts(config pluginConfigStruct) []pluginConfigMountStruct {
+	var mounts []pluginConfigMountStruct
+	for _, mount := range config.Mounts {
+		if mount.Type == "" || mount.Type == "bind" {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the value of a settable field, or a placeholder if the value is left to be set at install time
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginSettableValue(value string, name string, settable []string, field string) string {
+	if value == "" && stringInSlice(field, settable) {
+		return "<" + name + "." + field + " set at install time>"
+	}
+	return value
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the privileges granted to the plugin by --grant-all-permissions, in the same form as docker plugin install prompts for them
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginPrivileges(config pluginConfigStruct) []string {
+	var privileges []string
+
+	if config.Network.Type != "" && config.Network.Type != "null" && config.Network.Type != "none" {
+		privileges = append(privileges, "network: "+config.Network.Type)
+	}
+
+	var mounts []string
+	for _, mount := range getPluginHostMounts(config) {
+		mounts = append(mounts, getPluginSettableValue(mount.Source, mount.Name, mount.Settable, "source"))
+	}
+	if len(mounts) > 0 {
+		privileges = append(privileges, "mount: "+strings.Join(mounts, ", "))
+	}
+
+	var devices []string
+	for _, device := range config.Linux.Devices {
+		devices = append(devices, getPluginSettableValue(device.Path, device.Name, device.Settable, "path"))
+	}
+	if len(devices) > 0 {
+		privileges = append(privileges, "device: "+strings.Join(devices, ", "))
+	}
+
+	if config.Linux.AllowAllDevices {
+		privileges = append(privileges, "allow-all-devices: true")
+	}
+	if config.PidHost {
+		privileges = append(privileges, "host pid namespace: true")
+	}
+	if config.IpcHost {
+		privileges = append(privileges, "host ipc namespace: true")
+	}
+	if capabilities := getPluginCapabilities(config); len(capabilities) > 0 {
+		privileges = append(privileges, "capabilities: "+strings.Join(capabilities, ", "))
+	}
+
+	return privileges
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Lists the privileges requested by the plugin and reports the findings of every lint rule. Returns false if a rule reported an error.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func lintPluginConfig(config pluginConfigStruct) bool {
+	var errorFindings int
+	var warningFindings int
+
+	inspectionData.Privileges = getPluginPrivileges(config)
+	if len(inspectionData.Privileges) == 0 {
+		printMessage("The plugin does not request any privileges.")
+	} else {
+		printMessage("Privileges granted to the plugin by --grant-all-permissions:")
+		for _, privilege := range inspectionData.Privileges {
+			printMessage("    - " + privilege)
+		}
+	}
+
+	for _, rule := range pluginConfigLintRules {
+		for _, finding := range rule.check(config) {
+			message := fmt.Sprintf("Plugin configuration (%s): %s. %s", rule.Name, finding, rule.Rationale)
+			if rule.Severity == lintSeverityError {
+				errorFindings++
+				printError(message)
+			} else {
+				warningFindings++
+				printWarning(message)
+			}
+		}
+	}
+
+	if errorFindings == 0 && warningFindings == 0 {
+		printSuccess("Plugin configuration: the plugin does not request any privileges beyond " + strings.Join(lintAllowedCapabilities, "/"))
+	}
+
+	return errorFindings == 0
+}
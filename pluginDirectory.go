@@ -0,0 +1,137 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Inspection of an unpublished Docker Networking Plugin from a local plugin directory.
+//
+// The directory has the layout docker plugin create consumes: the plugin configuration in config.json and the root
+// filesystem in rootfs/. The configuration is read instead of the registry config blob and the rootfs is indexed in
+// place instead of being downloaded, then the plugin is created with docker plugin create for the network tests.
+//
+
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/inspect_docker_image/dockerAPI"
+)
+
+var pluginDirectory string
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Reads the plugin configuration of a plugin directory into the dockerAPI configuration blob and the full plugin configuration
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func readPluginDirectory(directory string, configurationBlob *dockerAPI.DockerPluginConfigurationBlob, config *pluginConfigStruct) error {
+	if info, err := os.Stat(filepath.Join(directory, "rootfs")); err != nil || !info.IsDir() {
+		return errors.New("the plugin directory " + directory + " does not contain a rootfs directory")
+	}
+
+	configBlob, err := ioutil.ReadFile(filepath.Join(directory, "config.json"))
+	if err != nil {
+		return errors.New("unable to read the configuration of the plugin directory " + directory + ", " + err.Error())
+	}
+
+	if err := json.Unmarshal(configBlob, configurationBlob); err != nil {
+		return errors.New("the config.json of the plugin directory " + directory + " is not valid JSON, " + err.Error())
+	}
+
+	return decodePluginConfig(configBlob, config)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Indexes the files of the rootfs of a plugin directory in place. The rootfs is reported as a single layer.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func indexPluginDirectoryRootfs(rootfsDirectory string) ([]rootfsLayerStruct, error) {
+	var layer = rootfsLayerStruct{Digest: "directory " + rootfsDirectory}
+
+	pluginRootfsDirectory = rootfsDirectory
+	pluginRootfsTemporary = false
+	pluginRootfsFiles = map[string]*rootfsFileStruct{}
+
+	err := filepath.Walk(rootfsDirectory, func(hostPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(rootfsDirectory, hostPath)
+		if err != nil || relativePath == "." {
+			return err
+		}
+
+		var linkname string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if linkname, err = os.Readlink(hostPath); err != nil {
+				return err
+			}
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// The tar header carries the owner of the file on the platforms which have one, as it would in a layer
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		header, err := tar.FileInfoHeader(info, linkname)
+		if err != nil {
+			return err
+		}
+
+		rootfsPath := path.Clean("/" + filepath.ToSlash(relativePath))
+		pluginRootfsFiles[rootfsPath] = &rootfsFileStruct{
+			Path:     rootfsPath,
+			Mode:     info.Mode(),
+			UID:      header.Uid,
+			GID:      header.Gid,
+			Size:     header.Size,
+			Linkname: linkname,
+		}
+
+		layer.Files++
+		if info.Mode().IsRegular() {
+			layer.UncompressedSize += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	layer.CompressedSize = layer.UncompressedSize
+	return []rootfsLayerStruct{layer}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Creates and enables the Docker Networking Plugin from a plugin directory
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func createDockerNetworkingPlugin(pluginName string, directory string) bool {
+	output, err := runCommand("docker plugin create " + shellQuote(pluginName) + " " + shellQuote(directory))
+	if err != nil {
+		var errMessage = fmt.Sprintf("Unable to create the Docker Networking Plugin from the plugin directory %s!", directory)
+		if output != "" {
+			errMessage = errMessage + ", " + output
+		} else {
+			errMessage = errMessage + ", " + err.Error()
+		}
+		printError(errMessage)
+		return false
+	}
+
+	output, err = runCommand("docker plugin enable " + shellQuote(pluginName))
+	if err != nil {
+		var errMessage = fmt.Sprintf("Unable to enable the Docker Networking Plugin %s!", pluginName)
+		if output != "" {
+			errMessage = errMessage + ", " + output
+		} else {
+			errMessage = errMessage + ", " + err.Error()
+		}
+		printError(errMessage)
+		removeDockerNetworkingPlugin(pluginName)
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("Docker networking plugin %s has been created from the plugin directory %s.", pluginName, directory))
+	return true
+}
@@ -0,0 +1,50 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Validation of the interface types declared by the plugin.
+//
+// Only plugins implementing the network driver or the IPAM driver interface are installed, and the declared interface types
+// decide which test suite is run.
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const pluginInterfaceNetworkDriver = "docker.networkdriver/1.0"
+const pluginInterfaceIPAMDriver = "docker.ipamdriver/1.0"
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks the interface types declared by the plugin. Returns whether the plugin is a network driver and whether it is an IPAM driver.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func checkPluginInterfaceTypes(pluginName string, interfaceTypes []string) (bool, bool) {
+	var networkDriver bool
+	var ipamDriver bool
+
+	if len(interfaceTypes) == 0 {
+		printError(fmt.Sprintf("The plugin %s does not declare any interface types! The plugin will not be installed.", pluginName))
+		return false, false
+	}
+
+	for _, interfaceType := range interfaceTypes {
+		switch strings.TrimSpace(interfaceType) {
+		case pluginInterfaceNetworkDriver:
+			networkDriver = true
+			printSuccess(fmt.Sprintf("The plugin %s declares the network driver interface %s", pluginName, pluginInterfaceNetworkDriver))
+		case pluginInterfaceIPAMDriver:
+			ipamDriver = true
+			printSuccess(fmt.Sprintf("The plugin %s declares the IPAM driver interface %s", pluginName, pluginInterfaceIPAMDriver))
+		default:
+			printWarning(fmt.Sprintf("The plugin %s declares the interface %s which is not tested by this tool", pluginName, interfaceType))
+		}
+	}
+
+	if !networkDriver && !ipamDriver {
+		printError(fmt.Sprintf("The plugin %s is not a networking plugin! It declares %s instead of %s or %s. The plugin will not be installed.",
+			pluginName, strings.Join(interfaceTypes, ", "), pluginInterfaceNetworkDriver, pluginInterfaceIPAMDriver))
+	}
+
+	return networkDriver, ipamDriver
+}
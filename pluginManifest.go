@@ -0,0 +1,288 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Manifests, manifest lists and OCI indexes of the Docker Networking Plugin.
+//
+// A multi-architecture plugin is published as a manifest list (or OCI index) with one manifest per platform. Every platform
+// manifest and its plugin configuration is inspected, and the manifest of the host platform is used for the rest of the
+// inspection, as docker plugin install does.
+//
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/docker/inspect_docker_image/dockerAPI"
+)
+
+const mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+const mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+const mediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+const mediaTypeOCIIndex = "application/vnd.oci.image.index.v1+json"
+const mediaTypePluginConfig = "application/vnd.docker.plugin.v1+json"
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a manifest, a manifest list or an OCI index
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type registryDescriptorStruct struct {
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant"`
+	} `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type registryManifestStruct struct {
+	SchemaVersion int                        `json:"schemaVersion"`
+	MediaType     string                     `json:"mediaType"`
+	Config        registryDescriptorStruct   `json:"config"`
+	Layers        []registryDescriptorStruct `json:"layers"`
+	Manifests     []registryDescriptorStruct `json:"manifests"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a platform variant of the plugin included in the HTML report and the JSON output
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginPlatformStruct struct {
+	Platform       string   `json:"Platform"`
+	Digest         string   `json:"Digest"`
+	ConfigDigest   string   `json:"ConfigDigest"`
+	Layers         int      `json:"Layers"`
+	Size           int64    `json:"Size"`
+	Entrypoint     string   `json:"Entrypoint"`
+	InterfaceTypes []string `json:"InterfaceTypes"`
+	Inspected      bool     `json:"Inspected"`
+	Error          string   `json:"Error,omitempty"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns whether the manifest is a manifest list or an OCI index
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func isManifestList(manifest registryManifestStruct) bool {
+	return manifest.MediaType == mediaTypeDockerManifestList || manifest.MediaType == mediaTypeOCIIndex ||
+		(manifest.MediaType == "" && len(manifest.Manifests) > 0)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads the raw content of a manifest by tag or digest from the registry. Returns the content and its media type.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func downloadRegistryManifest(repository string, reference string) ([]byte, string, error) {
+	response, err := registryGet(repository, "/manifests/"+reference,
+		strings.Join([]string{mediaTypeDockerManifestList, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeOCIManifest}, ", "))
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, response.Header.Get("Content-Type"), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gets a manifest by tag or digest from the registry, or from the plugin archive. Returns the manifest, its raw content and its digest.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRegistryManifest(repository string, reference string) (registryManifestStruct, []byte, string, error) {
+	var manifest registryManifestStruct
+	var content []byte
+	var mediaType string
+	var err error
+
+	if pluginArchive != "" {
+		content, mediaType, err = readArchiveManifest(reference)
+	} else {
+		content, mediaType, err = downloadRegistryManifest(repository, reference)
+	}
+	if err != nil {
+		return manifest, nil, "", err
+	}
+
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return manifest, nil, "", errors.New("the downloaded manifest does not match its digest " + reference)
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifest, nil, "", errors.New("the manifest " + reference + " is not valid JSON, " + err.Error())
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = mediaType
+	}
+
+	return manifest, content, digest, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the platform of a manifest list entry in the os/architecture[/variant] form
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatPlatform(descriptor registryDescriptorStruct) string {
+	if descriptor.Platform == nil {
+		return "unknown"
+	}
+
+	platform := descriptor.Platform.OS + "/" + descriptor.Platform.Architecture
+	if descriptor.Platform.Variant != "" {
+		platform += "/" + descriptor.Platform.Variant
+	}
+	return platform
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads the manifest and the plugin configuration of a platform and returns its summary
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func inspectPluginPlatform(repository string, platform string, manifest registryManifestStruct, digest string) pluginPlatformStruct {
+	var config pluginConfigStruct
+	var summary = pluginPlatformStruct{
+		Platform:     platform,
+		Digest:       digest,
+		ConfigDigest: manifest.Config.Digest,
+		Layers:       len(manifest.Layers),
+		Size:         manifest.Config.Size,
+	}
+
+	for _, layer := range manifest.Layers {
+		summary.Size += layer.Size
+	}
+
+	if manifest.Config.MediaType != "" && manifest.Config.MediaType != mediaTypePluginConfig {
+		summary.Error = "the manifest references a " + manifest.Config.MediaType + " config instead of a plugin config"
+		return summary
+	}
+	if len(manifest.Layers) == 0 {
+		summary.Error = "the manifest does not contain any layers"
+		return summary
+	}
+	if err := getPluginConfig(repository, manifest.Config.Digest, &config); err != nil {
+		summary.Error = "unable to get the plugin configuration, " + err.Error()
+		return summary
+	}
+
+	summary.Entrypoint = strings.Join(config.Entrypoint, " ")
+	summary.InterfaceTypes = config.Interface.Types
+	return summary
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Inspects every platform of a manifest list and returns the digest of the platform manifest matching the host
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func inspectPluginPlatforms(repository string, manifestList registryManifestStruct) (string, error) {
+	var architectures []string
+	var hostPlatform = "linux/" + runtime.GOARCH
+	var selectedDigest, selectedPlatform string
+
+	for _, descriptor := range manifestList.Manifests {
+		platform := formatPlatform(descriptor)
+
+		manifest, _, digest, err := getRegistryManifest(repository, descriptor.Digest)
+		var summary pluginPlatformStruct
+		if err != nil {
+			summary = pluginPlatformStruct{Platform: platform, Digest: descriptor.Digest, Error: err.Error()}
+		} else {
+			summary = inspectPluginPlatform(repository, platform, manifest, digest)
+		}
+
+		if summary.Error != "" {
+			printError(fmt.Sprintf("Platform %s (%s) of the Docker Networking Plugin can not be inspected! %s", platform, descriptor.Digest, summary.Error))
+		} else {
+			printSuccess(fmt.Sprintf("Platform %s of the Docker Networking Plugin has been inspected: %d layers, %s, interface types %s",
+				platform, summary.Layers, formatSize(summary.Size), strings.Join(summary.InterfaceTypes, ", ")))
+			if selectedDigest == "" && descriptor.Platform != nil && descriptor.Platform.OS == "linux" && descriptor.Platform.Architecture == runtime.GOARCH {
+				selectedDigest, selectedPlatform = descriptor.Digest, platform
+			}
+		}
+
+		inspectionData.Platforms = append(inspectionData.Platforms, summary)
+		if descriptor.Platform != nil && !stringInSlice(descriptor.Platform.Architecture, architectures) {
+			architectures = append(architectures, descriptor.Platform.Architecture)
+		}
+	}
+
+	if len(inspectionData.Platforms) == 0 {
+		return "", errors.New("the manifest list of the Docker Networking Plugin does not contain any platforms")
+	}
+	printMessage(fmt.Sprintf("The Docker Networking Plugin supports the architectures: %s", strings.Join(architectures, ", ")))
+
+	if selectedDigest == "" {
+		for index, platform := range inspectionData.Platforms {
+			if platform.Error == "" {
+				selectedDigest, selectedPlatform = manifestList.Manifests[index].Digest, platform.Platform
+				break
+			}
+		}
+		if selectedDigest == "" {
+			return "", errors.New("none of the platforms of the Docker Networking Plugin could be inspected")
+		}
+		printWarning(fmt.Sprintf("The Docker Networking Plugin does not support the host platform %s! The rest of the inspection uses the platform %s.",
+			hostPlatform, selectedPlatform))
+	}
+
+	for index := range inspectionData.Platforms {
+		inspectionData.Platforms[index].Inspected = inspectionData.Platforms[index].Digest == selectedDigest
+	}
+
+	return selectedDigest, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gets the manifest of the plugin. A manifest list or OCI index is resolved to the manifest of the host platform after every platform
+// has been inspected. Returns the digest of the plugin reference.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginManifest(repository string, reference string, pluginManifest *dockerAPI.DockerImageManifest) (string, error) {
+	manifest, content, digest, err := getRegistryManifest(repository, reference)
+	if err != nil {
+		return "", err
+	}
+
+	if isManifestList(manifest) {
+		platformDigest, err := inspectPluginPlatforms(repository, manifest)
+		if err != nil {
+			return "", err
+		}
+		if manifest, content, _, err = getRegistryManifest(repository, platformDigest); err != nil {
+			return "", err
+		}
+	} else {
+		printMessage("The Docker Networking Plugin is published as a single manifest, it does not declare its platform.")
+	}
+
+	if len(manifest.Layers) == 0 {
+		return "", errors.New("the manifest of the Docker Networking Plugin does not contain any layers")
+	}
+	if err := json.Unmarshal(content, pluginManifest); err != nil {
+		return "", errors.New("the manifest of the Docker Networking Plugin is not valid JSON, " + err.Error())
+	}
+
+	return digest, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads the config blob referenced by the plugin manifest into the dockerAPI configuration blob. Returns the raw config blob
+// so the full plugin configuration is decoded from the same download.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginConfigurationBlob(repository string, configDigest string, configurationBlob *dockerAPI.DockerPluginConfigurationBlob) ([]byte, error) {
+	configBlob, err := getRegistryBlob(repository, configDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(configBlob, configurationBlob); err != nil {
+		return nil, errors.New("the plugin configuration is not valid JSON, " + err.Error())
+	}
+
+	return configBlob, nil
+}
@@ -0,0 +1,295 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Record and replay of plugin API sessions.
+//
+// A session recorded by the plugin API trace is saved to a file and can later be replayed against another version of the plugin.
+// The responses of the plugin are compared field by field to the recorded responses and any regression is reported.
+//
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var recordSessionFile string
+var replaySessionFile string
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Response fields which are expected to change from one run to the next and are not compared when replaying a session
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var replayIgnoredFields = []string{
+	"Interface.MacAddress",
+	"InterfaceName.SrcName",
+}
+
+var arrayIndexRegexp = regexp.MustCompile(`\[[0-9]+\]`)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a recorded plugin API session
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginSessionStruct struct {
+	DockerNetworkingPlugin string                    `json:"DockerNetworkingPlugin"`
+	RecordedAt             string                    `json:"RecordedAt"`
+	Exchanges              []pluginAPIExchangeStruct `json:"Exchanges"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the networks, endpoints and joins created by a replayed session and not removed by it
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type replayStateStruct struct {
+	networks  map[string]bool
+	endpoints map[remoteDriverEndpointRequestStruct]bool
+	joins     map[remoteDriverEndpointRequestStruct]bool
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Saves the recorded plugin API session to a file
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func savePluginSession(fileName string, pluginName string, exchanges []pluginAPIExchangeStruct) error {
+	session := pluginSessionStruct{
+		DockerNetworkingPlugin: pluginName,
+		RecordedAt:             time.Now().Format(time.RFC3339),
+		Exchanges:              exchanges,
+	}
+
+	sessionJSON, err := json.MarshalIndent(session, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fileName, sessionJSON, 0644)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Loads a recorded plugin API session from a file
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func loadPluginSession(fileName string) (pluginSessionStruct, error) {
+	var session pluginSessionStruct
+
+	sessionJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return session, err
+	}
+
+	if err := json.Unmarshal(sessionJSON, &session); err != nil {
+		return session, errors.New("the session file is not valid JSON, " + err.Error())
+	}
+
+	if len(session.Exchanges) == 0 {
+		return session, errors.New("the session file does not contain any plugin API calls")
+	}
+
+	return session, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Flattens a decoded JSON value into a map of field paths (for example StaticRoutes[0].NextHop) and values
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func flattenJSON(path string, value interface{}, fields map[string]interface{}) {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typedValue {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			flattenJSON(fieldPath, fieldValue, fields)
+		}
+	case []interface{}:
+		for index, elementValue := range typedValue {
+			flattenJSON(fmt.Sprintf("%s[%d]", path, index), elementValue, fields)
+		}
+	default:
+		fields[path] = value
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns true if the response field is not compared when replaying a session
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func replayFieldIgnored(path string) bool {
+	return stringInSlice(arrayIndexRegexp.ReplaceAllString(path, ""), replayIgnoredFields)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares a replayed response to the recorded response field by field and returns the differences
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func diffPluginResponses(recordedResponse string, replayedResponse []byte) ([]string, error) {
+	var recordedValue, replayedValue interface{}
+	var differences []string
+
+	if err := json.Unmarshal([]byte(recordedResponse), &recordedValue); err != nil {
+		return nil, errors.New("the recorded response is not valid JSON")
+	}
+	if err := json.Unmarshal(replayedResponse, &replayedValue); err != nil {
+		return nil, fmt.Errorf("the response is not valid JSON: %s", truncateString(string(replayedResponse), 200))
+	}
+
+	recordedFields := map[string]interface{}{}
+	replayedFields := map[string]interface{}{}
+	flattenJSON("", recordedValue, recordedFields)
+	flattenJSON("", replayedValue, replayedFields)
+
+	var paths []string
+	for path := range recordedFields {
+		paths = append(paths, path)
+	}
+	for path := range replayedFields {
+		if _, ok := recordedFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if replayFieldIgnored(path) {
+			continue
+		}
+
+		recordedFieldValue, recorded := recordedFields[path]
+		replayedFieldValue, replayed := replayedFields[path]
+		fieldName := path
+		if fieldName == "" {
+			fieldName = "(response)"
+		}
+
+		switch {
+		case !replayed:
+			differences = append(differences, fmt.Sprintf("%s is missing (was %v)", fieldName, recordedFieldValue))
+		case !recorded:
+			differences = append(differences, fmt.Sprintf("%s was added (%v)", fieldName, replayedFieldValue))
+		case fmt.Sprint(recordedFieldValue) != fmt.Sprint(replayedFieldValue):
+			differences = append(differences, fmt.Sprintf("%s changed from %v to %v", fieldName, recordedFieldValue, replayedFieldValue))
+		}
+	}
+
+	return differences, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Tracks the networks, endpoints and joins created and removed by a replayed request
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (state *replayStateStruct) track(method string, request []byte) {
+	var endpoint remoteDriverEndpointRequestStruct
+	if err := json.Unmarshal(request, &endpoint); err != nil {
+		return
+	}
+
+	switch method {
+	case "NetworkDriver.CreateNetwork":
+		state.networks[endpoint.NetworkID] = true
+	case "NetworkDriver.DeleteNetwork":
+		delete(state.networks, endpoint.NetworkID)
+	case "NetworkDriver.CreateEndpoint":
+		state.endpoints[endpoint] = true
+	case "NetworkDriver.DeleteEndpoint":
+		delete(state.endpoints, endpoint)
+	case "NetworkDriver.Join":
+		state.joins[endpoint] = true
+	case "NetworkDriver.Leave":
+		delete(state.joins, endpoint)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Leaves, deletes the endpoints and deletes the networks a partial session left in the plugin, so the network tests start clean.
+// Returns the number of networks and endpoints removed.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (state *replayStateStruct) cleanup(client *http.Client) (int, int) {
+	for endpoint := range state.joins {
+		callPluginAPI(client, "NetworkDriver.Leave", endpoint, nil)
+	}
+	for endpoint := range state.endpoints {
+		callPluginAPI(client, "NetworkDriver.DeleteEndpoint", endpoint, nil)
+	}
+	for networkID := range state.networks {
+		callPluginAPI(client, "NetworkDriver.DeleteNetwork", remoteDriverNetworkRequestStruct{NetworkID: networkID}, nil)
+	}
+
+	return len(state.networks), len(state.endpoints)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Replays a recorded plugin API session against the installed plugin and reports every response which differs from the recording
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func replayPluginSession(pluginName string, fileName string) bool {
+	var regressions int
+	var replayed int
+
+	session, err := loadPluginSession(fileName)
+	if err != nil {
+		printError("Unable to load the plugin API session " + fileName + "! " + err.Error())
+		return false
+	}
+
+	socketPath, err := getPluginSocketPath(pluginName)
+	if err != nil {
+		printError("Unable to find the socket of the Docker Networking Plugin! " + err.Error())
+		return false
+	}
+
+	printMessage(fmt.Sprintf("Replaying %d plugin API calls recorded with plugin %s on %s", len(session.Exchanges), session.DockerNetworkingPlugin, session.RecordedAt))
+
+	client := newPluginAPIClient(socketPath, pluginAPITimeout)
+	state := replayStateStruct{
+		networks:  map[string]bool{},
+		endpoints: map[remoteDriverEndpointRequestStruct]bool{},
+		joins:     map[remoteDriverEndpointRequestStruct]bool{},
+	}
+	for index, exchange := range session.Exchanges {
+		callName := fmt.Sprintf("Replay: call %d (%s)", index+1, exchange.Method)
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// Requests which failed or were truncated when recorded can not be replayed
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		if exchange.Error != "" || !json.Valid([]byte(exchange.Request)) {
+			printWarning(callName + " was skipped, the recorded request is incomplete")
+			continue
+		}
+
+		replayed++
+		statusCode, responseBody, err := postPluginAPI(client, exchange.Method, []byte(exchange.Request))
+		state.track(exchange.Method, []byte(exchange.Request))
+		if err != nil {
+			regressions++
+			printError(callName + " failed! " + err.Error())
+			continue
+		}
+
+		if statusCode != exchange.StatusCode {
+			regressions++
+			printError(fmt.Sprintf("%s returned HTTP status %d, the recorded HTTP status was %d", callName, statusCode, exchange.StatusCode))
+		}
+
+		differences, err := diffPluginResponses(exchange.Response, responseBody)
+		if err != nil {
+			regressions++
+			printError(callName + " could not be compared! " + err.Error())
+			continue
+		}
+
+		for _, difference := range differences {
+			regressions++
+			printError(callName + " response differs from the recording: " + difference)
+		}
+	}
+
+	if networks, endpoints := state.cleanup(client); networks > 0 || endpoints > 0 {
+		printMessage(fmt.Sprintf("Removed %d networks and %d endpoints the replayed session left in the plugin", networks, endpoints))
+	}
+
+	if regressions > 0 {
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("Replayed %d plugin API calls against plugin %s, all responses match the recorded session %s", replayed, pluginName, fileName))
+	return true
+}
@@ -0,0 +1,227 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Recording proxy placed between dockerd and the Docker Networking Plugin's socket during the test phases.
+//
+// The plugin's socket is moved aside and the proxy listens on its original path, forwarding every request to the plugin and
+// capturing each request/response pair with a timestamp, so the exact payloads returned by the driver end up in the report.
+//
+// Moving the socket only affects new connections. dockerd keeps the connections it opened to the plugin when the plugin was
+// enabled (Plugin.Activate, NetworkDriver.GetCapabilities) and reuses them while they stay open, so the calls made over those
+// connections are not recorded and the trace can be partial or empty.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const pluginTraceMaxPayloadSize = 64 * 1024
+
+var traceEnabled = false
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a plugin API request/response pair
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginAPIExchangeStruct struct {
+	Time                 string  `json:"Time"`
+	Method               string  `json:"Method"`
+	StatusCode           int     `json:"StatusCode"`
+	DurationMilliseconds float64 `json:"DurationMilliseconds"`
+	Request              string  `json:"Request"`
+	Response             string  `json:"Response"`
+	Error                string  `json:"Error,omitempty"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the recording proxy
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type pluginTraceProxyStruct struct {
+	socketPath      string
+	pluginSocket    string
+	listener        net.Listener
+	client          *http.Client
+	exchangesMutex  sync.Mutex
+	exchanges       []pluginAPIExchangeStruct
+	serverErrorChan chan error
+	signals         chan os.Signal
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the payload as a string, truncating it if it is too large to be included in the report
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatTracePayload(payload []byte) string {
+	if len(payload) > pluginTraceMaxPayloadSize {
+		return string(payload[:pluginTraceMaxPayloadSize]) + fmt.Sprintf("... (%d bytes truncated)", len(payload)-pluginTraceMaxPayloadSize)
+	}
+
+	return string(payload)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Forwards a request from dockerd to the plugin and records the request/response pair
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (proxy *pluginTraceProxyStruct) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	startTime := time.Now()
+	exchange := pluginAPIExchangeStruct{
+		Time:   startTime.Format(time.RFC3339Nano),
+		Method: strings.TrimPrefix(req.URL.Path, "/"),
+	}
+
+	requestBody, err := ioutil.ReadAll(req.Body)
+	exchange.Request = formatTracePayload(requestBody)
+	if err != nil {
+		exchange.Error = err.Error()
+		proxy.record(exchange, startTime)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Forward the request to the plugin's socket which was moved aside
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	forwardRequest, err := http.NewRequest(req.Method, "http://plugin"+req.URL.RequestURI(), bytes.NewReader(requestBody))
+	if err != nil {
+		exchange.Error = err.Error()
+		proxy.record(exchange, startTime)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	forwardRequest.Header = req.Header
+
+	response, err := proxy.client.Do(forwardRequest)
+	if err != nil {
+		exchange.Error = err.Error()
+		proxy.record(exchange, startTime)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	exchange.StatusCode = response.StatusCode
+	exchange.Response = formatTracePayload(responseBody)
+	if err != nil {
+		exchange.Error = err.Error()
+	}
+	proxy.record(exchange, startTime)
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Return the plugin's response to dockerd unchanged
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for header, values := range response.Header {
+		for _, value := range values {
+			rw.Header().Add(header, value)
+		}
+	}
+	rw.WriteHeader(response.StatusCode)
+	rw.Write(responseBody)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Records a request/response pair
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (proxy *pluginTraceProxyStruct) record(exchange pluginAPIExchangeStruct, startTime time.Time) {
+	exchange.DurationMilliseconds = float64(time.Since(startTime)) / float64(time.Millisecond)
+
+	proxy.exchangesMutex.Lock()
+	proxy.exchanges = append(proxy.exchanges, exchange)
+	proxy.exchangesMutex.Unlock()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Moves the plugin's socket aside and starts the recording proxy on the socket's original path
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func startPluginTraceProxy(pluginName string) (*pluginTraceProxyStruct, error) {
+	socketPath, err := getPluginSocketPath(pluginName)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := &pluginTraceProxyStruct{
+		socketPath:      socketPath,
+		pluginSocket:    socketPath + ".traced",
+		serverErrorChan: make(chan error, 1),
+		signals:         make(chan os.Signal, 1),
+	}
+
+	if err := os.Rename(proxy.socketPath, proxy.pluginSocket); err != nil {
+		return nil, err
+	}
+
+	proxy.listener, err = net.Listen("unix", proxy.socketPath)
+	if err != nil {
+		os.Rename(proxy.pluginSocket, proxy.socketPath)
+		return nil, err
+	}
+
+	proxy.client = newPluginAPIClient(proxy.pluginSocket, 0)
+
+	go func() {
+		proxy.serverErrorChan <- http.Serve(proxy.listener, proxy)
+	}()
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Move the plugin's socket back to its original path when the inspection is interrupted
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	signal.Notify(proxy.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, interrupted := <-proxy.signals; interrupted {
+			proxy.listener.Close()
+			os.Remove(proxy.socketPath)
+			os.Rename(proxy.pluginSocket, proxy.socketPath)
+			exitInspection(1)
+		}
+	}()
+
+	return proxy, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Stops the recording proxy, moves the plugin's socket back to its original path and returns the recorded request/response pairs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (proxy *pluginTraceProxyStruct) stop() ([]pluginAPIExchangeStruct, error) {
+	signal.Stop(proxy.signals)
+	close(proxy.signals)
+
+	proxy.listener.Close()
+	<-proxy.serverErrorChan
+
+	os.Remove(proxy.socketPath)
+	err := os.Rename(proxy.pluginSocket, proxy.socketPath)
+
+	proxy.exchangesMutex.Lock()
+	defer proxy.exchangesMutex.Unlock()
+
+	return proxy.exchanges, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Prints the recorded request/response pairs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func printPluginAPITrace(exchanges []pluginAPIExchangeStruct) {
+	var lineFormat = "| %-35s | %-45s | %6s | %10s |"
+	var separator = "+" + strings.Repeat("-", 37) + "+" + strings.Repeat("-", 47) + "+" + strings.Repeat("-", 8) + "+" + strings.Repeat("-", 12) + "+"
+
+	printMessage(separator)
+	printMessage(fmt.Sprintf(lineFormat, "Time", "Method", "Status", "Time (ms)"))
+	printMessage(separator)
+	for _, exchange := range exchanges {
+		status := fmt.Sprint(exchange.StatusCode)
+		if exchange.Error != "" {
+			status = "error"
+		}
+		printMessage(fmt.Sprintf(lineFormat, exchange.Time, truncateString(exchange.Method, 45), status, fmt.Sprintf("%.1f", exchange.DurationMilliseconds)))
+	}
+	printMessage(separator)
+}
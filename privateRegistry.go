@@ -0,0 +1,235 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Private registries: custom certificate authorities, client certificates and insecure registries.
+//
+// The registry client trusts the certificate authority of --registry-ca-cert and presents the client certificate of
+// --registry-client-cert and --registry-client-key. With --registry-insecure the certificate of the registry is not verified
+// and plain HTTP is used when the registry does not speak HTTPS, as dockerd does for its insecure registries. The plugin is
+// installed by the digest inspected in the same registry, and dockerd is checked for the matching trust configuration.
+//
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/inspect_docker_image/dockerAPI"
+)
+
+const dockerCertsDirectory = "/etc/docker/certs.d"
+
+var registryCACertFile string
+var registryClientCertFile string
+var registryClientKeyFile string
+var registryInsecure bool
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the registry configuration of dockerd returned by docker info
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type dockerRegistryConfigStruct struct {
+	InsecureRegistryCIDRs []string `json:"InsecureRegistryCIDRs"`
+	IndexConfigs          map[string]struct {
+		Name   string `json:"Name"`
+		Secure bool   `json:"Secure"`
+	} `json:"IndexConfigs"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Configures the TLS settings of the registry client from the private registry options
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func configureRegistryTransport() error {
+	if registryCACertFile == "" && registryClientCertFile == "" && registryClientKeyFile == "" && !registryInsecure {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: registryInsecure}
+
+	if registryCACertFile != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		caCert, err := ioutil.ReadFile(registryCACertFile)
+		if err != nil {
+			return errors.New("unable to read the registry CA certificate " + registryCACertFile + ", " + err.Error())
+		}
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return errors.New("the registry CA certificate " + registryCACertFile + " does not contain any PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if (registryClientCertFile == "") != (registryClientKeyFile == "") {
+		return errors.New("the registry client certificate and key must be specified together")
+	}
+	if registryClientCertFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(registryClientCertFile, registryClientKeyFile)
+		if err != nil {
+			return errors.New("unable to load the registry client certificate " + registryClientCertFile + ", " + err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	registryHTTPClient.Transport = transport
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Sends a request to the registry. An insecure registry which does not speak HTTPS is retried over plain HTTP, and the registry
+// API endpoint is switched to HTTP for the requests which follow.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func doRegistryRequest(request *http.Request) (*http.Response, error) {
+	response, err := registryHTTPClient.Do(request)
+	if err == nil || !registryInsecure || request.URL.Scheme != "https" {
+		return response, err
+	}
+
+	httpRequest := request.Clone(request.Context())
+	httpRequest.URL.Scheme = "http"
+	response, httpErr := registryHTTPClient.Do(httpRequest)
+	if httpErr != nil {
+		return nil, errors.New(err.Error() + ", and over plain HTTP " + httpErr.Error())
+	}
+
+	if strings.HasPrefix(dockerAPI.DockerRegistryAPIEndpoint, "https://") {
+		dockerAPI.DockerRegistryAPIEndpoint = "http://" + strings.TrimPrefix(dockerAPI.DockerRegistryAPIEndpoint, "https://")
+		printWarning("The registry " + getRegistryHost() + " does not speak HTTPS, the plugin is downloaded over plain HTTP.")
+	}
+
+	return response, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the host[:port] of the registry API endpoint, docker.io for Docker Hub
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRegistryHost() string {
+	endpoint, err := url.Parse(dockerAPI.DockerRegistryAPIEndpoint)
+	if err != nil || endpoint.Host == "" {
+		return dockerHubRegistry
+	}
+	if endpoint.Host == "registry-1.docker.io" || endpoint.Host == "index.docker.io" {
+		return dockerHubRegistry
+	}
+	return endpoint.Host
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the reference docker plugin install pulls the plugin with: the repository in the registry the plugin was inspected in,
+// pinned to the inspected digest
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginPullReference() string {
+	return formatDockerReference(dockerReferenceStruct{
+		Registry:   getRegistryHost(),
+		Repository: inspectionData.DockerNetworkingPluginRepo,
+		Digest:     inspectionData.DockerNetworkingPluginDigest,
+	})
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether dockerd treats a registry as insecure, either by name or by the CIDR of its address
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func dockerRegistryIsInsecure(registry string, config dockerRegistryConfigStruct) bool {
+	if index, found := config.IndexConfigs[registry]; found {
+		return !index.Secure
+	}
+
+	host := registry
+	if splitHost, _, err := net.SplitHostPort(registry); err == nil {
+		host = splitHost
+	}
+	addresses, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+
+	for _, cidr := range config.InsecureRegistryCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if network.Contains(address) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether the files of a glob pattern exist in the certs.d directory of a registry
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func dockerCertsFileExists(registry string, pattern string) bool {
+	matches, err := filepath.Glob(filepath.Join(dockerCertsDirectory, registry, pattern))
+	return err == nil && len(matches) > 0
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks that dockerd is configured to pull from the private registry the way the registry client was: as an insecure
+// registry, or trusting its CA and presenting a client certificate from /etc/docker/certs.d/<registry>
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func checkDockerRegistryTrust() {
+	registry := getRegistryHost()
+	if registry == dockerHubRegistry {
+		return
+	}
+
+	if registryInsecure {
+		var config dockerRegistryConfigStruct
+		output, err := runCommand("docker info --format '{{json .RegistryConfig}}'")
+		if err != nil {
+			printWarning("Unable to get the registry configuration of dockerd! " + output)
+		} else if err := json.Unmarshal([]byte(output), &config); err != nil {
+			printWarning("The registry configuration of dockerd is not valid JSON! " + err.Error())
+		} else if !dockerRegistryIsInsecure(registry, config) {
+			printWarning("The registry " + registry + " is not an insecure registry of dockerd, docker plugin install may fail. " +
+				"Add it to \"insecure-registries\" in /etc/docker/daemon.json and restart dockerd.")
+		}
+	}
+
+	if os.Getenv("DOCKER_HOST") != "" {
+		return
+	}
+	if registryCACertFile != "" && !dockerCertsFileExists(registry, "*.crt") {
+		printWarning("dockerd does not trust a CA for the registry " + registry + ", docker plugin install may fail. " +
+			"Copy " + registryCACertFile + " to " + filepath.Join(dockerCertsDirectory, registry, "ca.crt") + ".")
+	}
+	if registryClientCertFile != "" && (!dockerCertsFileExists(registry, "*.cert") || !dockerCertsFileExists(registry, "*.key")) {
+		printWarning("dockerd has no client certificate for the registry " + registry + ", docker plugin install may fail. " +
+			"Copy " + registryClientCertFile + " and " + registryClientKeyFile + " to " + filepath.Join(dockerCertsDirectory, registry, "client.cert") +
+			" and " + filepath.Join(dockerCertsDirectory, registry, "client.key") + ".")
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Verifies that the installed plugin was pulled by the inspected digest
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func verifyInstalledPluginDigest(pluginName string) bool {
+	output, err := runCommand("docker plugin inspect --format '{{.PluginReference}}' " + shellQuote(pluginName))
+	if err != nil {
+		printError("Unable to get the reference of the installed Docker Networking Plugin! " + output)
+		return false
+	}
+
+	if !strings.HasSuffix(output, "@"+inspectionData.DockerNetworkingPluginDigest) {
+		printError("The installed Docker Networking Plugin " + output + " does not match the inspected digest " + inspectionData.DockerNetworkingPluginDigest + "!")
+		return false
+	}
+
+	printSuccess("The installed Docker Networking Plugin matches the inspected digest " + inspectionData.DockerNetworkingPluginDigest + ".")
+	return true
+}
@@ -0,0 +1,226 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Minimal Docker Registry HTTP API V2 client used to download the raw blobs of the Docker Networking Plugin.
+//
+// The registry's bearer token challenge is followed to obtain a pull token for the repository, using the registry credentials
+// or the identity token of a credential helper, or anonymously if there are none.
+//
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/inspect_docker_image/dockerAPI"
+)
+
+const registryTimeout = 10 * time.Minute
+
+var registryHTTPClient = &http.Client{Timeout: registryTimeout}
+var registryUser string
+var registryPassword string
+var registryIdentityToken string
+var registryTokens = map[string]string{}
+
+var registryChallengeRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the token returned by the registry's token service
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type registryTokenStruct struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gets a pull token for the repository by following the registry's WWW-Authenticate bearer challenge
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRegistryToken(repository string, challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", errors.New("unsupported registry authentication challenge: " + challenge)
+	}
+
+	parameters := map[string]string{}
+	for _, match := range registryChallengeRegexp.FindAllStringSubmatch(challenge, -1) {
+		parameters[match[1]] = match[2]
+	}
+	if parameters["realm"] == "" {
+		return "", errors.New("the registry authentication challenge does not contain a realm: " + challenge)
+	}
+
+	query := url.Values{}
+	if parameters["service"] != "" {
+		query.Set("service", parameters["service"])
+	}
+	query.Set("scope", "repository:"+repository+":pull")
+
+	var request *http.Request
+	var err error
+	if registryIdentityToken != "" {
+		query.Set("grant_type", "refresh_token")
+		query.Set("refresh_token", registryIdentityToken)
+		query.Set("client_id", "inspectDockerNetworkingPlugin")
+		request, err = http.NewRequest("POST", parameters["realm"], strings.NewReader(query.Encode()))
+		if err != nil {
+			return "", err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		request, err = http.NewRequest("GET", parameters["realm"]+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if registryUser != "" {
+			request.SetBasicAuth(registryUser, registryPassword)
+		}
+	}
+
+	response, err := registryHTTPClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the registry token service returned HTTP status %d: %s", response.StatusCode, truncateString(string(body), 200))
+	}
+
+	var token registryTokenStruct
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", errors.New("the registry token service response is not valid JSON, " + err.Error())
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	return token.Token, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Sends a GET request for a path (for example /blobs/sha256:...) of the repository to the registry, authenticating if challenged
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func registryGet(repository string, path string, accept string) (*http.Response, error) {
+	var requestURL string
+
+	for attempt := 0; attempt < 2; attempt++ {
+		requestURL = strings.TrimSuffix(dockerAPI.DockerRegistryAPIEndpoint, "/") + "/v2/" + repository + path
+		request, err := http.NewRequest("GET", requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		if token := registryTokens[repository]; token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		response, err := doRegistryRequest(request)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := response.Header.Get("WWW-Authenticate")
+			response.Body.Close()
+
+			token, err := getRegistryToken(repository, challenge)
+			if err != nil {
+				return nil, err
+			}
+			registryTokens[repository] = token
+			continue
+		}
+
+		if response.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(response.Body)
+			response.Body.Close()
+			return nil, fmt.Errorf("the registry returned HTTP status %d for %s: %s", response.StatusCode, requestURL, truncateString(string(body), 200))
+		}
+
+		return response, nil
+	}
+
+	return nil, errors.New("the registry did not accept the authentication token for " + requestURL)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a reader which verifies the data read against a sha256 digest when the end of the data is reached
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type digestVerifyingReaderStruct struct {
+	body   io.ReadCloser
+	hash   hash.Hash
+	digest string
+}
+
+func (reader *digestVerifyingReaderStruct) Read(data []byte) (int, error) {
+	count, err := reader.body.Read(data)
+	reader.hash.Write(data[:count])
+
+	if err == io.EOF && "sha256:"+hex.EncodeToString(reader.hash.Sum(nil)) != reader.digest {
+		return count, errors.New("the downloaded blob does not match its digest " + reader.digest)
+	}
+
+	return count, err
+}
+
+func (reader *digestVerifyingReaderStruct) Close() error {
+	return reader.body.Close()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Opens a blob of the repository, or of the plugin archive, for streaming. The blob is verified against its digest when it has
+// been read completely.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func openRegistryBlob(repository string, digest string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+
+	if pluginArchive != "" {
+		blobFile, err := openArchiveBlob(digest)
+		if err != nil {
+			return nil, err
+		}
+		body = blobFile
+	} else {
+		response, err := registryGet(repository, "/blobs/"+digest, "")
+		if err != nil {
+			return nil, err
+		}
+		body = response.Body
+	}
+
+	if !strings.HasPrefix(digest, "sha256:") {
+		return body, nil
+	}
+
+	return &digestVerifyingReaderStruct{body: body, hash: sha256.New(), digest: digest}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads a blob of the repository and verifies it against its digest
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRegistryBlob(repository string, digest string) ([]byte, error) {
+	blobReader, err := openRegistryBlob(repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blobReader.Close()
+
+	return ioutil.ReadAll(blobReader)
+}
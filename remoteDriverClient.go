@@ -0,0 +1,452 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Client for the libnetwork remote network driver protocol (docker.networkdriver/1.0).
+//
+// The protocol test talks HTTP/JSON directly to the plugin's socket and validates every response against the remote driver specification:
+//
+//     https://github.com/docker/libnetwork/blob/master/docs/remote.md
+//
+// This pinpoints which API call of the driver is broken instead of surfacing a generic docker network create error.
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const pluginAPIContentType = "application/vnd.docker.plugins.v1.2+json"
+const pluginAPITimeout = 30 * time.Second
+const pluginRuntimeDirectory = "/run/docker/plugins"
+
+var protocolTest = false
+var pluginSocketPath string
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// These structures define the requests and responses of the remote network driver protocol
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type remoteDriverErrorResponseStruct struct {
+	Err string `json:"Err"`
+}
+
+type remoteDriverActivateResponseStruct struct {
+	Implements []string `json:"Implements"`
+}
+
+type remoteDriverCapabilitiesResponseStruct struct {
+	Scope             string `json:"Scope"`
+	ConnectivityScope string `json:"ConnectivityScope"`
+}
+
+type remoteDriverIPAMDataStruct struct {
+	AddressSpace string            `json:"AddressSpace"`
+	Pool         string            `json:"Pool"`
+	Gateway      string            `json:"Gateway"`
+	AuxAddresses map[string]string `json:"AuxAddresses"`
+}
+
+type remoteDriverCreateNetworkRequestStruct struct {
+	NetworkID string                       `json:"NetworkID"`
+	Options   map[string]interface{}       `json:"Options"`
+	IPv4Data  []remoteDriverIPAMDataStruct `json:"IPv4Data"`
+	IPv6Data  []remoteDriverIPAMDataStruct `json:"IPv6Data"`
+}
+
+type remoteDriverNetworkRequestStruct struct {
+	NetworkID string `json:"NetworkID"`
+}
+
+type remoteDriverEndpointInterfaceStruct struct {
+	Address     string `json:"Address"`
+	AddressIPv6 string `json:"AddressIPv6"`
+	MacAddress  string `json:"MacAddress"`
+}
+
+type remoteDriverCreateEndpointRequestStruct struct {
+	NetworkID  string                               `json:"NetworkID"`
+	EndpointID string                               `json:"EndpointID"`
+	Interface  *remoteDriverEndpointInterfaceStruct `json:"Interface"`
+	Options    map[string]interface{}               `json:"Options"`
+}
+
+type remoteDriverCreateEndpointResponseStruct struct {
+	Interface *remoteDriverEndpointInterfaceStruct `json:"Interface"`
+}
+
+type remoteDriverEndpointRequestStruct struct {
+	NetworkID  string `json:"NetworkID"`
+	EndpointID string `json:"EndpointID"`
+}
+
+type remoteDriverJoinRequestStruct struct {
+	NetworkID  string                 `json:"NetworkID"`
+	EndpointID string                 `json:"EndpointID"`
+	SandboxKey string                 `json:"SandboxKey"`
+	Options    map[string]interface{} `json:"Options"`
+}
+
+type remoteDriverStaticRouteStruct struct {
+	Destination string `json:"Destination"`
+	RouteType   int    `json:"RouteType"`
+	NextHop     string `json:"NextHop"`
+}
+
+type remoteDriverJoinResponseStruct struct {
+	InterfaceName struct {
+		SrcName   string `json:"SrcName"`
+		DstPrefix string `json:"DstPrefix"`
+	} `json:"InterfaceName"`
+	Gateway               string                          `json:"Gateway"`
+	GatewayIPv6           string                          `json:"GatewayIPv6"`
+	StaticRoutes          []remoteDriverStaticRouteStruct `json:"StaticRoutes"`
+	DisableGatewayService bool                            `json:"DisableGatewayService"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the path of the installed plugin's socket. The socket of a managed plugin lives in the plugin's runtime directory.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPluginSocketPath(pluginName string) (string, error) {
+	if pluginSocketPath != "" {
+		return pluginSocketPath, nil
+	}
+
+	if dockerPluginConfigurationBlob.Interface.Socket == "" {
+		return "", errors.New("the Docker Networking Plugin configuration does not contain an interface socket")
+	}
+
+	pluginID, err := runCommand("docker plugin inspect --format '{{.Id}}' " + shellQuote(pluginName))
+	if err != nil {
+		return "", errors.New(err.Error() + ", " + pluginID)
+	}
+
+	return filepath.Join(pluginRuntimeDirectory, pluginID, dockerPluginConfigurationBlob.Interface.Socket), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns an HTTP client which connects to the plugin's unix socket
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func newPluginAPIClient(socketPath string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Posts a raw request body to a plugin API method (for example NetworkDriver.CreateNetwork) and returns the HTTP status code and raw response body
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func postPluginAPI(client *http.Client, method string, requestBody []byte) (int, []byte, error) {
+	request, err := http.NewRequest("POST", "http://plugin/"+method, bytes.NewReader(requestBody))
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("Content-Type", pluginAPIContentType)
+	request.Header.Set("Accept", pluginAPIContentType)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return response.StatusCode, responseBody, err
+	}
+
+	return response.StatusCode, responseBody, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Calls a plugin API method and decodes the response. Returns an error if the call failed, the response is not valid JSON
+// or the driver returned an error in the Err field.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func callPluginAPI(client *http.Client, method string, request interface{}, response interface{}) error {
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	statusCode, responseBody, err := postPluginAPI(client, method, requestBody)
+	if err != nil {
+		return err
+	}
+
+	var errorResponse remoteDriverErrorResponseStruct
+	if err := json.Unmarshal(responseBody, &errorResponse); err != nil {
+		return fmt.Errorf("the response is not valid JSON (HTTP status %d): %s", statusCode, truncateString(string(responseBody), 200))
+	}
+
+	if errorResponse.Err != "" {
+		return fmt.Errorf("the driver returned an error (HTTP status %d): %s", statusCode, errorResponse.Err)
+	}
+
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status %d: %s", statusCode, truncateString(string(responseBody), 200))
+	}
+
+	if response != nil {
+		if err := json.Unmarshal(responseBody, response); err != nil {
+			return fmt.Errorf("the response does not match the remote driver specification: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a random 64 character hex ID like the network and endpoint IDs generated by Docker
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func generateRandomID() string {
+	id := make([]byte, 32)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Reports the result of a plugin API call. Returns true if the call passed.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func reportPluginAPICall(method string, err error) bool {
+	if err != nil {
+		printError(fmt.Sprintf("%s failed! %s", method, err.Error()))
+		return false
+	}
+
+	printSuccess(fmt.Sprintf("%s conforms to the remote driver specification", method))
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Validates the response of NetworkDriver.Join
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func validateJoinResponse(joinResponse remoteDriverJoinResponseStruct) error {
+	if joinResponse.InterfaceName.SrcName == "" || joinResponse.InterfaceName.DstPrefix == "" {
+		return errors.New("the response must contain InterfaceName.SrcName and InterfaceName.DstPrefix")
+	}
+
+	if _, err := net.InterfaceByName(joinResponse.InterfaceName.SrcName); err != nil {
+		return fmt.Errorf("the interface %s returned in InterfaceName.SrcName does not exist on the host", joinResponse.InterfaceName.SrcName)
+	}
+
+	if joinResponse.Gateway != "" && net.ParseIP(joinResponse.Gateway) == nil {
+		return fmt.Errorf("the Gateway %s is not a valid IP address", joinResponse.Gateway)
+	}
+
+	if joinResponse.GatewayIPv6 != "" && net.ParseIP(joinResponse.GatewayIPv6) == nil {
+		return fmt.Errorf("the GatewayIPv6 %s is not a valid IP address", joinResponse.GatewayIPv6)
+	}
+
+	for _, staticRoute := range joinResponse.StaticRoutes {
+		if _, _, err := net.ParseCIDR(staticRoute.Destination); err != nil {
+			return fmt.Errorf("the static route destination %s is not a valid CIDR", staticRoute.Destination)
+		}
+		switch staticRoute.RouteType {
+		case 0:
+			if net.ParseIP(staticRoute.NextHop) == nil {
+				return fmt.Errorf("the static route to %s has RouteType 0 (next hop) but the NextHop %s is not a valid IP address", staticRoute.Destination, staticRoute.NextHop)
+			}
+		case 1:
+		default:
+			return fmt.Errorf("the static route to %s has the invalid RouteType %d", staticRoute.Destination, staticRoute.RouteType)
+		}
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the IPv4 IPAM data passed to NetworkDriver.CreateNetwork, built from the IPAM test options
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getProtocolTestIPv4Data() ([]remoteDriverIPAMDataStruct, string) {
+	_, subnet, err := net.ParseCIDR(ipamTestOptions.Subnet)
+	if err != nil {
+		return []remoteDriverIPAMDataStruct{}, ""
+	}
+	prefixLength, _ := subnet.Mask.Size()
+
+	ipv4Data := remoteDriverIPAMDataStruct{
+		AddressSpace: "LocalDefault",
+		Pool:         subnet.String(),
+		AuxAddresses: map[string]string{},
+	}
+	if ipamTestOptions.Gateway != "" {
+		ipv4Data.Gateway = fmt.Sprintf("%s/%d", ipamTestOptions.Gateway, prefixLength)
+	}
+
+	var endpointAddress string
+	if ipamTestStaticIPAddress != "" {
+		endpointAddress = fmt.Sprintf("%s/%d", ipamTestStaticIPAddress, prefixLength)
+	}
+
+	return []remoteDriverIPAMDataStruct{ipv4Data}, endpointAddress
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the driver options passed to NetworkDriver.CreateNetwork in the same form Docker passes them
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getProtocolTestNetworkOptions() map[string]interface{} {
+	var genericOptions = map[string]string{}
+
+	for _, driverOption := range testNetworkOptions.DriverOptions {
+		driverOptionParts := strings.SplitN(driverOption, "=", 2)
+		if len(driverOptionParts) == 2 {
+			genericOptions[driverOptionParts[0]] = driverOptionParts[1]
+		} else {
+			genericOptions[driverOptionParts[0]] = ""
+		}
+	}
+
+	return map[string]interface{}{
+		"com.docker.network.enable_ipv6": false,
+		"com.docker.network.generic":     genericOptions,
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Talks directly to the plugin's socket and calls every remote network driver API method in the order Docker calls them,
+// validating each response against the remote driver specification
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runRemoteDriverProtocolTest(pluginName string) bool {
+	var passed = true
+
+	socketPath, err := getPluginSocketPath(pluginName)
+	if err != nil {
+		printError("Unable to find the socket of the Docker Networking Plugin! " + err.Error())
+		return false
+	}
+	printSuccess(fmt.Sprintf("Found the socket of the Docker Networking Plugin %s: %s", pluginName, socketPath))
+
+	client := newPluginAPIClient(socketPath, pluginAPITimeout)
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Plugin.Activate must report the plugin implements NetworkDriver
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	var activateResponse remoteDriverActivateResponseStruct
+	err = callPluginAPI(client, "Plugin.Activate", struct{}{}, &activateResponse)
+	if err == nil && !stringInSlice("NetworkDriver", activateResponse.Implements) {
+		err = fmt.Errorf("the plugin implements %v but not NetworkDriver", activateResponse.Implements)
+	}
+	if ok := reportPluginAPICall("Plugin.Activate", err); !ok {
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// NetworkDriver.GetCapabilities must return a valid scope
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	var capabilitiesResponse remoteDriverCapabilitiesResponseStruct
+	err = callPluginAPI(client, "NetworkDriver.GetCapabilities", struct{}{}, &capabilitiesResponse)
+	if err == nil && capabilitiesResponse.Scope != "local" && capabilitiesResponse.Scope != "global" {
+		err = fmt.Errorf("the Scope must be local or global, not %q", capabilitiesResponse.Scope)
+	}
+	if err == nil && capabilitiesResponse.ConnectivityScope != "" && capabilitiesResponse.ConnectivityScope != "local" && capabilitiesResponse.ConnectivityScope != "global" {
+		err = fmt.Errorf("the ConnectivityScope must be local or global, not %q", capabilitiesResponse.ConnectivityScope)
+	}
+	passed = reportPluginAPICall("NetworkDriver.GetCapabilities", err) && passed
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// NetworkDriver.CreateNetwork
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	networkID := generateRandomID()
+	endpointID := generateRandomID()
+	ipv4Data, endpointAddress := getProtocolTestIPv4Data()
+
+	err = callPluginAPI(client, "NetworkDriver.CreateNetwork", remoteDriverCreateNetworkRequestStruct{
+		NetworkID: networkID,
+		Options:   getProtocolTestNetworkOptions(),
+		IPv4Data:  ipv4Data,
+		IPv6Data:  []remoteDriverIPAMDataStruct{},
+	}, nil)
+	if ok := reportPluginAPICall("NetworkDriver.CreateNetwork", err); !ok {
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// NetworkDriver.CreateEndpoint. The driver must not return an address when one was supplied in the request.
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	var createEndpointResponse remoteDriverCreateEndpointResponseStruct
+	err = callPluginAPI(client, "NetworkDriver.CreateEndpoint", remoteDriverCreateEndpointRequestStruct{
+		NetworkID:  networkID,
+		EndpointID: endpointID,
+		Interface:  &remoteDriverEndpointInterfaceStruct{Address: endpointAddress},
+		Options:    map[string]interface{}{},
+	}, &createEndpointResponse)
+	if err == nil && createEndpointResponse.Interface != nil {
+		if createEndpointResponse.Interface.Address != "" && createEndpointResponse.Interface.Address != endpointAddress {
+			err = fmt.Errorf("the driver returned the address %s although the address %s was supplied", createEndpointResponse.Interface.Address, endpointAddress)
+		} else if createEndpointResponse.Interface.MacAddress != "" {
+			if _, macErr := net.ParseMAC(createEndpointResponse.Interface.MacAddress); macErr != nil {
+				err = fmt.Errorf("the MacAddress %s is not valid", createEndpointResponse.Interface.MacAddress)
+			}
+		}
+	}
+	createEndpointPassed := reportPluginAPICall("NetworkDriver.CreateEndpoint", err)
+	passed = createEndpointPassed && passed
+
+	if createEndpointPassed {
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// NetworkDriver.Join and NetworkDriver.Leave
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		var joinResponse remoteDriverJoinResponseStruct
+		err = callPluginAPI(client, "NetworkDriver.Join", remoteDriverJoinRequestStruct{
+			NetworkID:  networkID,
+			EndpointID: endpointID,
+			SandboxKey: "/var/run/docker/netns/" + endpointID[:12],
+			Options:    map[string]interface{}{},
+		}, &joinResponse)
+		if err == nil {
+			err = validateJoinResponse(joinResponse)
+		}
+		joinPassed := reportPluginAPICall("NetworkDriver.Join", err)
+		passed = joinPassed && passed
+
+		if joinPassed {
+			err = callPluginAPI(client, "NetworkDriver.Leave", remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID}, nil)
+			passed = reportPluginAPICall("NetworkDriver.Leave", err) && passed
+		}
+
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		// NetworkDriver.DeleteEndpoint
+		////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+		err = callPluginAPI(client, "NetworkDriver.DeleteEndpoint", remoteDriverEndpointRequestStruct{NetworkID: networkID, EndpointID: endpointID}, nil)
+		passed = reportPluginAPICall("NetworkDriver.DeleteEndpoint", err) && passed
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// NetworkDriver.DeleteNetwork
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	err = callPluginAPI(client, "NetworkDriver.DeleteNetwork", remoteDriverNetworkRequestStruct{NetworkID: networkID}, nil)
+	passed = reportPluginAPICall("NetworkDriver.DeleteNetwork", err) && passed
+
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns true if the string is in the slice
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func stringInSlice(value string, slice []string) bool {
+	for _, sliceValue := range slice {
+		if sliceValue == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,513 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Download, unpack and analysis of the Docker Networking Plugin rootfs.
+//
+// The layers referenced by the plugin manifest are downloaded and unpacked into a temporary directory, applying whiteouts
+// the same way the Docker daemon does. An index of every file with the mode and owner recorded in the layers is kept, since
+// the unpacked files do not carry setuid/setgid bits or their original owners. Symbolic links are resolved inside the rootfs
+// and never on the host.
+//
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const rootfsMaxUncompressedSize = 8 * 1024 * 1024 * 1024
+const rootfsMaxSymlinkHops = 40
+const rootfsMaxReportedFiles = 20
+const rootfsDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+var pluginRootfsDirectory string
+var pluginRootfsTemporary bool
+var pluginRootfsFiles = map[string]*rootfsFileStruct{}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a file of the unpacked rootfs as recorded in the layers
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type rootfsFileStruct struct {
+	Path     string
+	Mode     os.FileMode
+	UID      int
+	GID      int
+	Size     int64
+	Linkname string
+	Layer    int
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// These structures define the rootfs analysis results
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type rootfsLayerStruct struct {
+	Digest           string `json:"Digest"`
+	CompressedSize   int64  `json:"CompressedSize"`
+	UncompressedSize int64  `json:"UncompressedSize"`
+	Files            int    `json:"Files"`
+}
+
+type rootfsAnalysisStruct struct {
+	Layers                []rootfsLayerStruct `json:"Layers"`
+	TotalCompressedSize   int64               `json:"TotalCompressedSize"`
+	TotalUncompressedSize int64               `json:"TotalUncompressedSize"`
+	SetuidFiles           []string            `json:"SetuidFiles"`
+	SetgidFiles           []string            `json:"SetgidFiles"`
+	WorldWritableFiles    []string            `json:"WorldWritableFiles"`
+	Entrypoint            string              `json:"Entrypoint"`
+	EntrypointExists      bool                `json:"EntrypointExists"`
+	EntrypointExecutable  bool                `json:"EntrypointExecutable"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a reader which counts the bytes read
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type countingReaderStruct struct {
+	reader io.Reader
+	count  int64
+}
+
+func (reader *countingReaderStruct) Read(data []byte) (int, error) {
+	count, err := reader.reader.Read(data)
+	reader.count += int64(count)
+	return count, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the path on the host of a path inside the unpacked rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRootfsHostPath(rootfsPath string) string {
+	return filepath.Join(pluginRootfsDirectory, filepath.FromSlash(rootfsPath))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Resolves the symbolic links of a path inside the rootfs. Absolute link targets are resolved relative to the root of the rootfs,
+// so the resolved path can never point outside of it.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func resolveRootfsPath(name string, followFinalLink bool) (string, error) {
+	var resolved = "/"
+	var hops int
+
+	remaining := strings.Split(path.Clean("/"+name), "/")
+	for len(remaining) > 0 {
+		component := remaining[0]
+		remaining = remaining[1:]
+
+		if component == "" || component == "." {
+			continue
+		}
+		if component == ".." {
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		candidate := path.Join(resolved, component)
+		file, found := pluginRootfsFiles[candidate]
+		if !found || file.Mode&os.ModeSymlink == 0 || (len(remaining) == 0 && !followFinalLink) {
+			resolved = candidate
+			continue
+		}
+
+		hops++
+		if hops > rootfsMaxSymlinkHops {
+			return "", errors.New("too many levels of symbolic links in " + name)
+		}
+
+		target := file.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(resolved, target)
+		}
+		remaining = append(strings.Split(path.Clean("/"+target), "/"), remaining...)
+		resolved = "/"
+	}
+
+	return resolved, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes a path and everything below it from the unpacked rootfs and its index
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func removeRootfsPath(rootfsPath string) {
+	prefix := strings.TrimSuffix(rootfsPath, "/") + "/"
+	for filePath := range pluginRootfsFiles {
+		if filePath == rootfsPath || strings.HasPrefix(filePath, prefix) {
+			delete(pluginRootfsFiles, filePath)
+		}
+	}
+
+	os.RemoveAll(getRootfsHostPath(rootfsPath))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Unpacks an entry of a layer into the rootfs. Device nodes and FIFOs are only recorded in the index.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func unpackRootfsEntry(header *tar.Header, tarReader io.Reader, layer int) error {
+	name := path.Clean("/" + header.Name)
+	if name == "/" {
+		return nil
+	}
+
+	parent, err := resolveRootfsPath(path.Dir(name), true)
+	if err != nil {
+		return err
+	}
+	base := path.Base(name)
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// An opaque whiteout hides everything the lower layers put in the directory, a whiteout file hides a single path
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if base == ".wh..wh..opq" {
+		prefix := strings.TrimSuffix(parent, "/") + "/"
+		for filePath, file := range pluginRootfsFiles {
+			if strings.HasPrefix(filePath, prefix) && file.Layer < layer {
+				removeRootfsPath(filePath)
+			}
+		}
+		return nil
+	}
+	if strings.HasPrefix(base, ".wh.") {
+		removeRootfsPath(path.Join(parent, strings.TrimPrefix(base, ".wh.")))
+		return nil
+	}
+
+	target := path.Join(parent, base)
+	hostPath := getRootfsHostPath(target)
+	if existing, found := pluginRootfsFiles[target]; found && !(existing.Mode.IsDir() && header.Typeflag == tar.TypeDir) {
+		removeRootfsPath(target)
+	}
+
+	if err := os.MkdirAll(getRootfsHostPath(parent), 0755); err != nil {
+		return err
+	}
+
+	file := &rootfsFileStruct{
+		Path:     target,
+		Mode:     header.FileInfo().Mode(),
+		UID:      header.Uid,
+		GID:      header.Gid,
+		Size:     header.Size,
+		Linkname: header.Linkname,
+		Layer:    layer,
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// The unpacked files are always readable by the inspection and never setuid/setgid on the host
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(hostPath, 0755); err != nil {
+			return err
+		}
+		os.Chmod(hostPath, file.Mode.Perm()|0700)
+	case tar.TypeReg, tar.TypeRegA:
+		hostFile, err := os.OpenFile(hostPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(hostFile, tarReader)
+		hostFile.Close()
+		if err != nil {
+			return err
+		}
+		os.Chmod(hostPath, file.Mode.Perm()|0600)
+	case tar.TypeSymlink:
+		if err := os.Symlink(header.Linkname, hostPath); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linkTarget, err := resolveRootfsPath(header.Linkname, false)
+		if err != nil {
+			return err
+		}
+		linkedFile, found := pluginRootfsFiles[linkTarget]
+		if !found {
+			return errors.New("the hard link " + name + " points to the missing file " + header.Linkname)
+		}
+		if err := os.Link(getRootfsHostPath(linkTarget), hostPath); err != nil {
+			return err
+		}
+		linkedCopy := *linkedFile
+		linkedCopy.Path = target
+		linkedCopy.Layer = layer
+		file = &linkedCopy
+	}
+
+	pluginRootfsFiles[target] = file
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads a layer and unpacks it into the rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func unpackRootfsLayer(repository string, digest string, layer int, totalUncompressedSize *int64) (rootfsLayerStruct, error) {
+	var layerResults = rootfsLayerStruct{Digest: digest}
+
+	blobReader, err := openRegistryBlob(repository, digest)
+	if err != nil {
+		return layerResults, err
+	}
+	defer blobReader.Close()
+
+	compressedReader := &countingReaderStruct{reader: blobReader}
+	bufferedReader := bufio.NewReader(compressedReader)
+
+	var layerReader io.Reader = bufferedReader
+	magic, _ := bufferedReader.Peek(4)
+	if len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(bufferedReader)
+		if err != nil {
+			return layerResults, err
+		}
+		defer gzipReader.Close()
+		layerReader = gzipReader
+	} else if len(magic) == 4 && magic[0] == 0x28 && magic[1] == 0xb5 && magic[2] == 0x2f && magic[3] == 0xfd {
+		return layerResults, errors.New("zstd compressed layers are not supported")
+	}
+
+	tarReader := tar.NewReader(layerReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return layerResults, err
+		}
+
+		layerResults.Files++
+		if header.Typeflag == tar.TypeReg || header.Typeflag == tar.TypeRegA {
+			layerResults.UncompressedSize += header.Size
+			*totalUncompressedSize += header.Size
+			if *totalUncompressedSize > rootfsMaxUncompressedSize {
+				return layerResults, fmt.Errorf("the rootfs is larger than %s", formatSize(rootfsMaxUncompressedSize))
+			}
+		}
+
+		if err := unpackRootfsEntry(header, tarReader, layer); err != nil {
+			return layerResults, fmt.Errorf("unable to unpack %s, %s", header.Name, err.Error())
+		}
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Read the rest of the blob so it is verified against its digest
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if _, err := io.Copy(ioutil.Discard, bufferedReader); err != nil {
+		return layerResults, err
+	}
+	layerResults.CompressedSize = compressedReader.count
+
+	return layerResults, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads the layers of the plugin and unpacks them into a temporary rootfs directory
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func unpackPluginRootfs(repository string, layerDigests []string) ([]rootfsLayerStruct, error) {
+	var layers []rootfsLayerStruct
+	var totalUncompressedSize int64
+	var err error
+
+	pluginRootfsDirectory, err = ioutil.TempDir("", "inspectDockerNetworkingPlugin-rootfs-")
+	if err != nil {
+		return nil, err
+	}
+	pluginRootfsTemporary = true
+	pluginRootfsFiles = map[string]*rootfsFileStruct{}
+
+	for index, digest := range layerDigests {
+		layerResults, err := unpackRootfsLayer(repository, digest, index, &totalUncompressedSize)
+		if err != nil {
+			return layers, fmt.Errorf("layer %s: %s", digest, err.Error())
+		}
+		layers = append(layers, layerResults)
+	}
+
+	return layers, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Removes the unpacked rootfs. The rootfs of a plugin directory is left in place.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func removePluginRootfs() {
+	if pluginRootfsDirectory != "" && pluginRootfsTemporary {
+		os.RemoveAll(pluginRootfsDirectory)
+	}
+	pluginRootfsDirectory = ""
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the size in a human readable form
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	divisor, exponent := int64(unit), 0
+	for quotient := size / unit; quotient >= unit; quotient /= unit {
+		divisor *= unit
+		exponent++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(divisor), "KMGTPE"[exponent])
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the path of the entrypoint binary inside the rootfs. A name without a slash is looked up in the PATH of the plugin.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRootfsEntrypointPath(entrypoint string, workDir string, env []pluginConfigEnvStruct) string {
+	if strings.Contains(entrypoint, "/") {
+		if path.IsAbs(entrypoint) {
+			return path.Clean(entrypoint)
+		}
+		return path.Join("/", workDir, entrypoint)
+	}
+
+	searchPath := rootfsDefaultPath
+	for _, envVariable := range env {
+		if envVariable.Name == "PATH" && envVariable.Value != "" {
+			searchPath = envVariable.Value
+		}
+	}
+
+	for _, directory := range strings.Split(searchPath, ":") {
+		candidate := path.Join("/", directory, entrypoint)
+		if resolved, err := resolveRootfsPath(candidate, true); err == nil {
+			if _, found := pluginRootfsFiles[resolved]; found {
+				return candidate
+			}
+		}
+	}
+
+	return ""
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Reports a list of files, only the first few individually
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func reportRootfsFiles(files []string, description string, rationale string) {
+	for index, file := range files {
+		if index == rootfsMaxReportedFiles {
+			printWarning(fmt.Sprintf("Rootfs: %d more %s files are not listed.", len(files)-rootfsMaxReportedFiles, description))
+			break
+		}
+		printWarning(fmt.Sprintf("Rootfs: %s is %s. %s", file, description, rationale))
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Downloads and unpacks the plugin rootfs, or indexes the rootfs of the plugin directory, and reports its size, setuid/setgid
+// binaries, world-writable files and the entrypoint
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func analyzePluginRootfs(repository string, layerDigests []string, entrypoint []string, workDir string, env []pluginConfigEnvStruct) bool {
+	var passed = true
+	var analysis = rootfsAnalysisStruct{}
+	var layers []rootfsLayerStruct
+	var err error
+
+	if pluginDirectory != "" {
+		layers, err = indexPluginDirectoryRootfs(filepath.Join(pluginDirectory, "rootfs"))
+	} else {
+		layers, err = unpackPluginRootfs(repository, layerDigests)
+	}
+	if err != nil {
+		printError("Unable to get the rootfs of the Docker Networking Plugin! " + err.Error())
+		removePluginRootfs()
+		return false
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Report the size of each layer and of the whole rootfs
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	analysis.Layers = layers
+	for _, layer := range layers {
+		analysis.TotalCompressedSize += layer.CompressedSize
+		analysis.TotalUncompressedSize += layer.UncompressedSize
+	}
+	printRootfsLayers(analysis)
+	printSuccess(fmt.Sprintf("Rootfs: %d layers, %s compressed, %s uncompressed, %d files",
+		len(layers), formatSize(analysis.TotalCompressedSize), formatSize(analysis.TotalUncompressedSize), len(pluginRootfsFiles)))
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Look for setuid/setgid binaries and world-writable files
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	for filePath, file := range pluginRootfsFiles {
+		if file.Mode.IsRegular() && file.Mode&os.ModeSetuid != 0 {
+			analysis.SetuidFiles = append(analysis.SetuidFiles, fmt.Sprintf("%s (owner %d)", filePath, file.UID))
+		}
+		if file.Mode.IsRegular() && file.Mode&os.ModeSetgid != 0 {
+			analysis.SetgidFiles = append(analysis.SetgidFiles, fmt.Sprintf("%s (group %d)", filePath, file.GID))
+		}
+		if file.Mode&os.ModeSymlink == 0 && file.Mode&0002 != 0 && !(file.Mode.IsDir() && file.Mode&os.ModeSticky != 0) {
+			analysis.WorldWritableFiles = append(analysis.WorldWritableFiles, filePath)
+		}
+	}
+	sort.Strings(analysis.SetuidFiles)
+	sort.Strings(analysis.SetgidFiles)
+	sort.Strings(analysis.WorldWritableFiles)
+
+	reportRootfsFiles(analysis.SetuidFiles, "a setuid binary", "The plugin already runs as root, a setuid binary is not needed and widens the attack surface.")
+	reportRootfsFiles(analysis.SetgidFiles, "a setgid binary", "A setgid binary is not needed by a plugin and widens the attack surface.")
+	reportRootfsFiles(analysis.WorldWritableFiles, "world-writable", "Any process sharing the plugin's filesystem can modify it.")
+	if len(analysis.SetuidFiles) == 0 && len(analysis.SetgidFiles) == 0 && len(analysis.WorldWritableFiles) == 0 {
+		printSuccess("Rootfs: no setuid/setgid binaries or world-writable files were found")
+	}
+
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// Make sure the entrypoint exists and is executable
+	/////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	if len(entrypoint) == 0 {
+		printError("Rootfs: the plugin configuration does not contain an entrypoint!")
+		passed = false
+	} else {
+		analysis.Entrypoint = getRootfsEntrypointPath(entrypoint[0], workDir, env)
+		resolved, err := resolveRootfsPath(analysis.Entrypoint, true)
+		file, found := pluginRootfsFiles[resolved]
+
+		analysis.EntrypointExists = analysis.Entrypoint != "" && err == nil && found && file.Mode.IsRegular()
+		analysis.EntrypointExecutable = analysis.EntrypointExists && file.Mode&0111 != 0
+
+		switch {
+		case !analysis.EntrypointExists:
+			printError(fmt.Sprintf("Rootfs: the entrypoint %s does not exist in the rootfs!", entrypoint[0]))
+			passed = false
+		case !analysis.EntrypointExecutable:
+			printError(fmt.Sprintf("Rootfs: the entrypoint %s is not executable (mode %s)!", analysis.Entrypoint, file.Mode))
+			passed = false
+		default:
+			printSuccess(fmt.Sprintf("Rootfs: the entrypoint %s exists and is executable", analysis.Entrypoint))
+		}
+	}
+
+	inspectionData.RootfsAnalysis = &analysis
+	return passed
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Prints the layers of the rootfs as a table
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func printRootfsLayers(analysis rootfsAnalysisStruct) {
+	var lineFormat = "| %-71s | %12s | %14s | %8s |"
+	var separator = "+" + strings.Repeat("-", 73) + "+" + strings.Repeat("-", 14) + "+" + strings.Repeat("-", 16) + "+" + strings.Repeat("-", 10) + "+"
+
+	printMessage(separator)
+	printMessage(fmt.Sprintf(lineFormat, "Layer", "Compressed", "Uncompressed", "Files"))
+	printMessage(separator)
+	for _, layer := range analysis.Layers {
+		printMessage(fmt.Sprintf(lineFormat, truncateString(layer.Digest, 71), formatSize(layer.CompressedSize), formatSize(layer.UncompressedSize), fmt.Sprint(layer.Files)))
+	}
+	printMessage(separator)
+	printMessage(fmt.Sprintf(lineFormat, "Total", formatSize(analysis.TotalCompressedSize), formatSize(analysis.TotalUncompressedSize), fmt.Sprint(len(pluginRootfsFiles))))
+	printMessage(separator)
+}
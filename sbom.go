@@ -0,0 +1,493 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Software bill of materials (SBOM) of the Docker Networking Plugin rootfs.
+//
+// OS packages are read from the apk and dpkg databases of the unpacked rootfs (and from the rpm database if rpm is available on
+// the host), and Go modules from the build info embedded in the Go binaries. The SBOM is written as CycloneDX JSON next to
+// the HTML report.
+//
+
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const sbomMaxBinarySize = 512 * 1024 * 1024
+
+var sbomPackages []sbomPackageStruct
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a package found in the rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type sbomPackageStruct struct {
+	Type         string
+	Name         string
+	Source       string
+	Version      string
+	Architecture string
+	PURL         string
+	Location     string
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the SBOM summary included in the JSON output
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type sbomSummaryStruct struct {
+	File            string         `json:"File"`
+	Format          string         `json:"Format"`
+	OperatingSystem string         `json:"OperatingSystem"`
+	Packages        int            `json:"Packages"`
+	PackagesByType  map[string]int `json:"PackagesByType"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// These structures define the CycloneDX document
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type cycloneDXPropertyStruct struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXComponentStruct struct {
+	BOMRef     string                    `json:"bom-ref,omitempty"`
+	Type       string                    `json:"type"`
+	Name       string                    `json:"name"`
+	Version    string                    `json:"version,omitempty"`
+	PURL       string                    `json:"purl,omitempty"`
+	Properties []cycloneDXPropertyStruct `json:"properties,omitempty"`
+}
+
+type cycloneDXDocumentStruct struct {
+	BOMFormat    string `json:"bomFormat"`
+	SpecVersion  string `json:"specVersion"`
+	SerialNumber string `json:"serialNumber"`
+	Version      int    `json:"version"`
+	Metadata     struct {
+		Timestamp string `json:"timestamp"`
+		Tools     []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Component cycloneDXComponentStruct `json:"component"`
+	} `json:"metadata"`
+	Components []cycloneDXComponentStruct `json:"components"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Reads a regular file of the rootfs, following symbolic links inside the rootfs only
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func readRootfsFile(rootfsPath string) ([]byte, error) {
+	resolved, err := resolveRootfsPath(rootfsPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	file, found := pluginRootfsFiles[resolved]
+	if !found || !file.Mode.IsRegular() {
+		return nil, os.ErrNotExist
+	}
+
+	return ioutil.ReadFile(getRootfsHostPath(resolved))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the ID and VERSION_ID of /etc/os-release of the rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRootfsOperatingSystem() (string, string) {
+	var id, versionID string
+
+	osRelease, err := readRootfsFile("/etc/os-release")
+	if err != nil {
+		osRelease, err = readRootfsFile("/usr/lib/os-release")
+		if err != nil {
+			return "", ""
+		}
+	}
+
+	for _, line := range strings.Split(string(osRelease), "\n") {
+		keyValue := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+		value := strings.Trim(keyValue[1], `"'`)
+		switch keyValue[0] {
+		case "ID":
+			id = value
+		case "VERSION_ID":
+			versionID = value
+		}
+	}
+
+	return id, versionID
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Splits a package database made of blank line separated records of "Key: value" (dpkg) or "K:value" (apk) lines
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func parsePackageRecords(database []byte, separator string) []map[string]string {
+	var records []map[string]string
+	var record = map[string]string{}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(database)))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(record) > 0 {
+				records = append(records, record)
+				record = map[string]string{}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		keyValue := strings.SplitN(line, separator, 2)
+		if len(keyValue) == 2 {
+			record[keyValue[0]] = strings.TrimSpace(keyValue[1])
+		}
+	}
+	if len(record) > 0 {
+		records = append(records, record)
+	}
+
+	return records
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the package URL (https://github.com/package-url/purl-spec) of a package
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPackageURL(packageType string, namespace string, name string, version string, architecture string) string {
+	purl := "pkg:" + packageType + "/"
+	if namespace != "" {
+		purl += url.PathEscape(namespace) + "/"
+	}
+	purl += url.PathEscape(name)
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	if architecture != "" {
+		purl += "?arch=" + url.QueryEscape(architecture)
+	}
+
+	return purl
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the packages of the apk database
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getApkPackages(distribution string) []sbomPackageStruct {
+	var packages []sbomPackageStruct
+	const database = "/lib/apk/db/installed"
+
+	installed, err := readRootfsFile(database)
+	if err != nil {
+		return nil
+	}
+
+	if distribution == "" {
+		distribution = "alpine"
+	}
+	for _, record := range parsePackageRecords(installed, ":") {
+		if record["P"] == "" {
+			continue
+		}
+		packages = append(packages, sbomPackageStruct{
+			Type:         "apk",
+			Name:         record["P"],
+			Source:       record["o"],
+			Version:      record["V"],
+			Architecture: record["A"],
+			PURL:         getPackageURL("apk", distribution, record["P"], record["V"], record["A"]),
+			Location:     database,
+		})
+	}
+
+	return packages
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the packages of the dpkg database, including the per package status files of distroless images
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDpkgPackages(distribution string) []sbomPackageStruct {
+	var packages []sbomPackageStruct
+	var databases = []string{"/var/lib/dpkg/status"}
+
+	for filePath, file := range pluginRootfsFiles {
+		if strings.HasPrefix(filePath, "/var/lib/dpkg/status.d/") && file.Mode.IsRegular() && !strings.HasSuffix(filePath, ".md5sums") {
+			databases = append(databases, filePath)
+		}
+	}
+	sort.Strings(databases[1:])
+
+	if distribution == "" {
+		distribution = "debian"
+	}
+	for _, database := range databases {
+		status, err := readRootfsFile(database)
+		if err != nil {
+			continue
+		}
+
+		for _, record := range parsePackageRecords(status, ":") {
+			if record["Package"] == "" {
+				continue
+			}
+			if record["Status"] != "" && !strings.HasSuffix(record["Status"], " installed") {
+				continue
+			}
+			packages = append(packages, sbomPackageStruct{
+				Type:         "deb",
+				Name:         record["Package"],
+				Source:       strings.SplitN(record["Source"], " ", 2)[0],
+				Version:      record["Version"],
+				Architecture: record["Architecture"],
+				PURL:         getPackageURL("deb", distribution, record["Package"], record["Version"], record["Architecture"]),
+				Location:     database,
+			})
+		}
+	}
+
+	return packages
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the packages of the rpm database. The rpm database formats are read with the rpm command of the host.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getRpmPackages(distribution string) []sbomPackageStruct {
+	var packages []sbomPackageStruct
+	var databaseFound bool
+
+	for _, database := range []string{"/var/lib/rpm", "/usr/lib/sysimage/rpm"} {
+		if resolved, err := resolveRootfsPath(database, true); err == nil {
+			if _, found := pluginRootfsFiles[resolved]; found {
+				databaseFound = true
+			}
+		}
+	}
+	if !databaseFound {
+		return nil
+	}
+
+	if _, err := exec.LookPath("rpm"); err != nil {
+		printWarning("SBOM: the rootfs contains an rpm database but rpm is not installed on this host. The rpm packages are not included in the SBOM.")
+		return nil
+	}
+
+	output, err := runCommand("rpm --root " + shellQuote(pluginRootfsDirectory) + " -qa --queryformat '%{NAME}\\t%{EPOCH}:%{VERSION}-%{RELEASE}\\t%{ARCH}\\n'")
+	if err != nil {
+		printWarning("SBOM: unable to read the rpm database of the rootfs! " + output)
+		return nil
+	}
+
+	if distribution == "" {
+		distribution = "rpm"
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		version := strings.TrimPrefix(fields[1], "(none):")
+		packages = append(packages, sbomPackageStruct{
+			Type:         "rpm",
+			Name:         fields[0],
+			Version:      version,
+			Architecture: fields[2],
+			PURL:         getPackageURL("rpm", distribution, fields[0], version, fields[2]),
+			Location:     "/var/lib/rpm",
+		})
+	}
+
+	return packages
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the Go modules embedded in the Go binaries of the rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getGoModulePackages() []sbomPackageStruct {
+	var packages []sbomPackageStruct
+	var binaries []string
+
+	for filePath, file := range pluginRootfsFiles {
+		if file.Mode.IsRegular() && file.Mode&0111 != 0 && file.Size > 0 && file.Size <= sbomMaxBinarySize {
+			binaries = append(binaries, filePath)
+		}
+	}
+	sort.Strings(binaries)
+
+	for _, binary := range binaries {
+		buildInfo, err := buildinfo.ReadFile(getRootfsHostPath(binary))
+		if err != nil {
+			continue
+		}
+
+		packages = append(packages, sbomPackageStruct{
+			Type:     "golang",
+			Name:     "stdlib",
+			Version:  buildInfo.GoVersion,
+			PURL:     getPackageURL("golang", "", "stdlib", buildInfo.GoVersion, ""),
+			Location: binary,
+		})
+		if buildInfo.Main.Path != "" {
+			packages = append(packages, sbomPackageStruct{
+				Type:     "golang",
+				Name:     buildInfo.Main.Path,
+				Version:  buildInfo.Main.Version,
+				PURL:     "pkg:golang/" + buildInfo.Main.Path + "@" + url.PathEscape(buildInfo.Main.Version),
+				Location: binary,
+			})
+		}
+		for _, module := range buildInfo.Deps {
+			if module.Replace != nil {
+				module = module.Replace
+			}
+			packages = append(packages, sbomPackageStruct{
+				Type:     "golang",
+				Name:     module.Path,
+				Version:  module.Version,
+				PURL:     "pkg:golang/" + module.Path + "@" + url.PathEscape(module.Version),
+				Location: binary,
+			})
+		}
+	}
+
+	return packages
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns a random version 4 UUID used as the serial number of the CycloneDX document
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func generateUUID() string {
+	uuid := make([]byte, 16)
+	rand.Read(uuid)
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Writes the packages as a CycloneDX JSON document
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func writeCycloneDXDocument(fileName string, packages []sbomPackageStruct, operatingSystem string, operatingSystemVersion string) error {
+	var document = cycloneDXDocumentStruct{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + generateUUID(),
+		Version:      1,
+	}
+
+	document.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	document.Metadata.Tools = append(document.Metadata.Tools, struct {
+		Name string `json:"name"`
+	}{"inspectDockerNetworkingPlugin"})
+	document.Metadata.Component = cycloneDXComponentStruct{
+		Type:    "container",
+		Name:    inspectionData.DockerNetworkingPluginRepo,
+		Version: inspectionData.DockerNetworkingPluginTag,
+	}
+	if inspectionData.DockerNetworkingPluginReferenceDigest != "" {
+		document.Metadata.Component.Version = inspectionData.DockerNetworkingPluginReferenceDigest
+	}
+
+	if operatingSystem != "" {
+		document.Components = append(document.Components, cycloneDXComponentStruct{
+			BOMRef:  "os:" + operatingSystem + "@" + operatingSystemVersion,
+			Type:    "operating-system",
+			Name:    operatingSystem,
+			Version: operatingSystemVersion,
+		})
+	}
+
+	for index, sbomPackage := range packages {
+		document.Components = append(document.Components, cycloneDXComponentStruct{
+			BOMRef:  fmt.Sprintf("%s#%d", sbomPackage.PURL, index),
+			Type:    "library",
+			Name:    sbomPackage.Name,
+			Version: sbomPackage.Version,
+			PURL:    sbomPackage.PURL,
+			Properties: []cycloneDXPropertyStruct{
+				{"location", sbomPackage.Location},
+				{"package-type", sbomPackage.Type},
+			},
+		})
+	}
+
+	documentJSON, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fileName, documentJSON, 0644)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Builds the SBOM of the unpacked rootfs and writes it next to the HTML report
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func generatePluginSBOM() bool {
+	operatingSystem, operatingSystemVersion := getRootfsOperatingSystem()
+
+	sbomPackages = nil
+	sbomPackages = append(sbomPackages, getApkPackages(operatingSystem)...)
+	sbomPackages = append(sbomPackages, getDpkgPackages(operatingSystem)...)
+	sbomPackages = append(sbomPackages, getRpmPackages(operatingSystem)...)
+	sbomPackages = append(sbomPackages, getGoModulePackages()...)
+
+	summary := sbomSummaryStruct{
+		Format:          "CycloneDX 1.4 JSON",
+		OperatingSystem: strings.TrimSpace(operatingSystem + " " + operatingSystemVersion),
+		Packages:        len(sbomPackages),
+		PackagesByType:  map[string]int{},
+	}
+	for _, sbomPackage := range sbomPackages {
+		summary.PackagesByType[sbomPackage.Type]++
+	}
+
+	if err := os.MkdirAll(`html`, 0755); err != nil {
+		printError("Unable to create the html directory for the SBOM! " + err.Error())
+		return false
+	}
+
+	summary.File = `html/` + getReportFileNamePrefix() + "_sbom_" + todaysDateTime.Format("2006-01-02_03-04-05") + ".cdx.json"
+	if err := writeCycloneDXDocument(summary.File, sbomPackages, operatingSystem, operatingSystemVersion); err != nil {
+		printError("Unable to write the SBOM to " + summary.File + "! " + err.Error())
+		return false
+	}
+	inspectionData.SBOM = &summary
+
+	var packageTypes []string
+	for packageType, count := range summary.PackagesByType {
+		packageTypes = append(packageTypes, fmt.Sprintf("%d %s", count, packageType))
+	}
+	sort.Strings(packageTypes)
+
+	if len(sbomPackages) == 0 {
+		printWarning("SBOM: no OS packages or Go modules were found in the rootfs of the plugin. The SBOM written to " + summary.File + " is empty.")
+		return true
+	}
+
+	if summary.OperatingSystem != "" {
+		printSuccess(fmt.Sprintf("SBOM: the rootfs is based on %s", summary.OperatingSystem))
+	}
+	printSuccess(fmt.Sprintf("SBOM: %d packages (%s) were written to %s", len(sbomPackages), strings.Join(packageTypes, ", "), summary.File))
+
+	if inspectionData.verboseOutput {
+		for _, sbomPackage := range sbomPackages {
+			printMessage(fmt.Sprintf("    %-8s %-60s %-30s %s", sbomPackage.Type, sbomPackage.Name, sbomPackage.Version, sbomPackage.Location))
+		}
+	}
+
+	return true
+}
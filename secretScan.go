@@ -0,0 +1,238 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Secret and credential scan of the Docker Networking Plugin configuration and rootfs.
+//
+// The Env defaults, the Args and the files of the unpacked rootfs are matched against patterns of private keys, access tokens and
+// cloud credentials. Values assigned to password, secret, token and key names are reported when their entropy shows they are
+// not a placeholder. Every match is reported as an error with the secret redacted.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const secretMaxFileSize = 16 * 1024 * 1024
+const secretMaxReportedFindings = 50
+const secretMinimumEntropy = 3.0
+const secretRedactedPrefixLength = 4
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a secret pattern. The secret is the submatch of the pattern, a pattern without a submatch only marks a secret.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type secretRuleStruct struct {
+	Name         string
+	Pattern      *regexp.Regexp
+	CheckEntropy bool
+}
+
+var secretRules = []secretRuleStruct{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN (?:(?:RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{Name: "aws-access-key-id", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{Name: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{Name: "azure-storage-key", Pattern: regexp.MustCompile(`AccountKey=([A-Za-z0-9+/=]{88})`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`)},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{Name: "gitlab-token", Pattern: regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20})\b`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})\b`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,})\b`)},
+	{Name: "json-web-token", Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`)},
+	{Name: "docker-registry-auth", Pattern: regexp.MustCompile(`"auth"\s*:\s*"([A-Za-z0-9+/=]{16,})"`)},
+	{Name: "url-credentials", Pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@"']+:([^/\s:@"']{3,})@[^\s"']+`)},
+	{
+		Name:         "password-assignment",
+		Pattern:      regexp.MustCompile(`(?i)[a-z0-9_.-]*(?:password|passwd|secret|token|api_?key|access_?key|private_?key|credentials?)["']?\s*[:=]\s*["']?([^\s"'` + "`" + `;,]{8,})`),
+		CheckEntropy: true,
+	},
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Values which are placeholders or references to a secret set elsewhere rather than a secret
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var secretPlaceholders = []string{"changeme", "example", "placeholder", "redacted", "xxxxxxxx", "your_", "your-", "<", "$", "%", "{{"}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a secret found in the plugin configuration or rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type secretFindingStruct struct {
+	Rule     string `json:"Rule"`
+	Location string `json:"Location"`
+	Line     int    `json:"Line,omitempty"`
+	Match    string `json:"Match"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the Shannon entropy of a string in bits per character
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getShannonEntropy(value string) float64 {
+	var entropy float64
+	var counts = map[rune]int{}
+
+	for _, character := range value {
+		counts[character]++
+	}
+	length := float64(len([]rune(value)))
+	for _, count := range counts {
+		probability := float64(count) / length
+		entropy -= probability * math.Log2(probability)
+	}
+
+	return entropy
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether the value of an assignment looks like a real secret rather than a placeholder, a reference or a word
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func isLikelySecret(value string) bool {
+	lowerValue := strings.ToLower(value)
+	for _, placeholder := range secretPlaceholders {
+		if strings.Contains(lowerValue, placeholder) {
+			return false
+		}
+	}
+	return getShannonEntropy(value) >= secretMinimumEntropy
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Redacts a secret, keeping only its first characters and its length
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func redactSecret(secret string) string {
+	if len(secret) <= secretRedactedPrefixLength*2 {
+		return fmt.Sprintf("[redacted, %d characters]", len(secret))
+	}
+	return fmt.Sprintf("%s...[redacted, %d characters]", secret[:secretRedactedPrefixLength], len(secret))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether a match overlaps one of the matches already found in the line
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func overlapsSecretMatch(match []int, matched [][]int) bool {
+	for _, previous := range matched {
+		if match[0] < previous[1] && previous[0] < match[1] {
+			return true
+		}
+	}
+	return false
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the secrets found in a line, with the secret of each match redacted. A match overlapping an earlier match is not reported twice.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func findSecrets(line []byte, location string, lineNumber int) []secretFindingStruct {
+	var findings []secretFindingStruct
+	var matched [][]int
+
+	for _, rule := range secretRules {
+		for _, match := range rule.Pattern.FindAllSubmatchIndex(line, -1) {
+			if overlapsSecretMatch(match, matched) {
+				continue
+			}
+			redacted := string(line[match[0]:match[1]])
+			if len(match) >= 4 && match[2] >= 0 {
+				secret := string(line[match[2]:match[3]])
+				if rule.CheckEntropy && !isLikelySecret(secret) {
+					continue
+				}
+				redacted = string(line[match[0]:match[2]]) + redactSecret(secret) + string(line[match[3]:match[1]])
+			}
+			matched = append(matched, match)
+			findings = append(findings, secretFindingStruct{
+				Rule:     rule.Name,
+				Location: location,
+				Line:     lineNumber,
+				Match:    strings.ToValidUTF8(redacted, "?"),
+			})
+		}
+	}
+
+	return findings
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the secrets found in the Env defaults and the Args of the plugin configuration
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func findPluginConfigSecrets(config pluginConfigStruct) []secretFindingStruct {
+	var findings []secretFindingStruct
+
+	for _, env := range config.Env {
+		if env.Value == "" {
+			continue
+		}
+		findings = append(findings, findSecrets([]byte(env.Name+"="+env.Value), "plugin configuration Env "+env.Name, 0)...)
+	}
+
+	for index, arg := range config.Args.Value {
+		findings = append(findings, findSecrets([]byte(arg), fmt.Sprintf("plugin configuration Args.Value[%d]", index), 0)...)
+	}
+
+	return findings
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the secrets found in the regular files of the unpacked rootfs
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func findRootfsSecrets() []secretFindingStruct {
+	var findings []secretFindingStruct
+	var filePaths []string
+
+	for filePath, file := range pluginRootfsFiles {
+		if file.Mode.IsRegular() && file.Size > 0 && file.Size <= secretMaxFileSize {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		content, err := ioutil.ReadFile(getRootfsHostPath(filePath))
+		if err != nil {
+			printWarning("Secrets: unable to read the rootfs file " + filePath + "! " + err.Error())
+			continue
+		}
+
+		for index, line := range bytes.Split(content, []byte("\n")) {
+			findings = append(findings, findSecrets(line, filePath, index+1)...)
+		}
+	}
+
+	return findings
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Scans the plugin configuration and the unpacked rootfs for secrets. Returns false if a secret was found.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func scanPluginSecrets(config pluginConfigStruct) bool {
+	findings := findPluginConfigSecrets(config)
+	if pluginRootfsDirectory != "" {
+		findings = append(findings, findRootfsSecrets()...)
+	}
+	inspectionData.Secrets = findings
+
+	for index, finding := range findings {
+		if index == secretMaxReportedFindings {
+			printError(fmt.Sprintf("Secrets: %d more secrets are not listed.", len(findings)-secretMaxReportedFindings))
+			break
+		}
+		location := finding.Location
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s line %d", finding.Location, finding.Line)
+		}
+		printError(fmt.Sprintf("Secrets (%s): %s contains %s", finding.Rule, location, finding.Match))
+	}
+
+	if len(findings) == 0 {
+		if pluginRootfsDirectory != "" {
+			printSuccess("Secrets: no private keys, tokens, passwords or cloud credentials were found in the plugin configuration and rootfs")
+		} else {
+			printSuccess("Secrets: no private keys, tokens, passwords or cloud credentials were found in the plugin configuration")
+		}
+	}
+
+	return len(findings) == 0
+}
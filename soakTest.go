@@ -0,0 +1,186 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Soak test which repeatedly creates and deletes a Docker network using the Docker Networking Plugin.
+//
+// The latency of every create and delete operation is recorded and summarized as p50/p95/p99 percentiles.
+//
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const soakMaxReportedFailures = 5
+
+var soakIterations int
+var soakDuration time.Duration
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the soak test results of one type of operation (create or delete)
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type soakOperationResultsStruct struct {
+	Operation        string  `json:"Operation"`
+	Attempts         int     `json:"Attempts"`
+	Failures         int     `json:"Failures"`
+	P50Milliseconds  float64 `json:"P50Milliseconds"`
+	P95Milliseconds  float64 `json:"P95Milliseconds"`
+	P99Milliseconds  float64 `json:"P99Milliseconds"`
+	MaxMilliseconds  float64 `json:"MaxMilliseconds"`
+	latencies        []time.Duration
+	reportedFailures int
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the soak test results
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type soakResultsStruct struct {
+	Iterations int                          `json:"Iterations"`
+	Duration   string                       `json:"Duration"`
+	Operations []soakOperationResultsStruct `json:"Operations"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns true if a soak test was requested
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func soakTestRequested() bool {
+	return soakIterations > 0 || soakDuration > 0
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Runs a command for the soak test and records its latency. Only the first few failures are reported individually.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runSoakOperation(operationResults *soakOperationResultsStruct, iteration int, command string) bool {
+	startTime := time.Now()
+	output, err := runCommand(command)
+	operationResults.latencies = append(operationResults.latencies, time.Since(startTime))
+	operationResults.Attempts++
+
+	if err != nil {
+		operationResults.Failures++
+		if operationResults.reportedFailures < soakMaxReportedFailures {
+			operationResults.reportedFailures++
+			if output == "" {
+				output = err.Error()
+			}
+			printError(fmt.Sprintf("Soak test: Docker network %s failed on iteration %d! %s", operationResults.Operation, iteration, output))
+		}
+		return false
+	}
+
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the latency percentile (nearest rank) in milliseconds from the sorted latencies
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getLatencyPercentile(sortedLatencies []time.Duration, percentile float64) float64 {
+	if len(sortedLatencies) == 0 {
+		return 0
+	}
+
+	rank := int(percentile/100*float64(len(sortedLatencies))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sortedLatencies) {
+		rank = len(sortedLatencies) - 1
+	}
+
+	return float64(sortedLatencies[rank]) / float64(time.Millisecond)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Calculates the latency percentiles of an operation and reports them
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func summarizeSoakOperation(operationResults *soakOperationResultsStruct, pluginName string) {
+	sort.Slice(operationResults.latencies, func(i, j int) bool {
+		return operationResults.latencies[i] < operationResults.latencies[j]
+	})
+
+	operationResults.P50Milliseconds = getLatencyPercentile(operationResults.latencies, 50)
+	operationResults.P95Milliseconds = getLatencyPercentile(operationResults.latencies, 95)
+	operationResults.P99Milliseconds = getLatencyPercentile(operationResults.latencies, 99)
+	operationResults.MaxMilliseconds = getLatencyPercentile(operationResults.latencies, 100)
+
+	message := fmt.Sprintf("Soak test: %d Docker network %s operations using plugin %s, %d failures, latency p50 %.0fms p95 %.0fms p99 %.0fms max %.0fms",
+		operationResults.Attempts, operationResults.Operation, pluginName, operationResults.Failures,
+		operationResults.P50Milliseconds, operationResults.P95Milliseconds, operationResults.P99Milliseconds, operationResults.MaxMilliseconds)
+
+	if operationResults.Failures > 0 {
+		printError(message)
+	} else {
+		printSuccess(message)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Repeatedly creates and deletes the Docker test network for the requested number of iterations and/or duration, whichever comes first
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func runSoakTest(pluginName string) bool {
+	var createResults = soakOperationResultsStruct{Operation: "create"}
+	var deleteResults = soakOperationResultsStruct{Operation: "delete"}
+	var iteration int
+
+	createCommand := "docker network create --driver=" + shellQuote(pluginName) + getDockerNetworkCreateOptions(testNetworkOptions) + " " + testNetworkName
+	deleteCommand := "docker network rm " + testNetworkName
+
+	runCommand(deleteCommand)
+
+	startTime := time.Now()
+	for iteration = 1; ; iteration++ {
+		if soakIterations > 0 && iteration > soakIterations {
+			break
+		}
+		if soakDuration > 0 && time.Since(startTime) >= soakDuration {
+			break
+		}
+
+		if ok := runSoakOperation(&createResults, iteration, createCommand); !ok {
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			// Make sure a partially created network does not fail the next iteration
+			///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+			runCommand(deleteCommand)
+			continue
+		}
+
+		runSoakOperation(&deleteResults, iteration, deleteCommand)
+	}
+
+	summarizeSoakOperation(&createResults, pluginName)
+	summarizeSoakOperation(&deleteResults, pluginName)
+
+	inspectionData.SoakResults = &soakResultsStruct{
+		Iterations: iteration - 1,
+		Duration:   time.Since(startTime).Round(time.Second).String(),
+		Operations: []soakOperationResultsStruct{createResults, deleteResults},
+	}
+
+	printSoakResults(inspectionData.SoakResults)
+
+	return createResults.Failures == 0 && deleteResults.Failures == 0
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Prints the soak test results as a table
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func printSoakResults(soakResults *soakResultsStruct) {
+	var lineFormat = "| %-10s | %10s | %10s | %12s | %12s | %12s | %12s |"
+	var separator = "+" + strings.Repeat("-", 12) + "+" + strings.Repeat("-", 12) + "+" + strings.Repeat("-", 12) +
+		strings.Repeat("+"+strings.Repeat("-", 14), 4) + "+"
+
+	printMessage("")
+	printMessage(fmt.Sprintf("Soak test: %d iterations in %s", soakResults.Iterations, soakResults.Duration))
+	printMessage(separator)
+	printMessage(fmt.Sprintf(lineFormat, "Operation", "Attempts", "Failures", "p50 (ms)", "p95 (ms)", "p99 (ms)", "max (ms)"))
+	printMessage(separator)
+	for _, operationResults := range soakResults.Operations {
+		printMessage(fmt.Sprintf(lineFormat, operationResults.Operation, fmt.Sprint(operationResults.Attempts), fmt.Sprint(operationResults.Failures),
+			fmt.Sprintf("%.0f", operationResults.P50Milliseconds), fmt.Sprintf("%.0f", operationResults.P95Milliseconds),
+			fmt.Sprintf("%.0f", operationResults.P99Milliseconds), fmt.Sprintf("%.0f", operationResults.MaxMilliseconds)))
+	}
+	printMessage(separator)
+}
@@ -0,0 +1,949 @@
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Vulnerability scan of the Docker Networking Plugin SBOM.
+//
+// The packages of the SBOM are matched against a local advisory database in the OSV format (https://ossf.github.io/osv-schema/),
+// for example the all.zip dumps of https://osv-vulnerabilities.storage.googleapis.com. The database can be a JSON file, a
+// directory of JSON files or a zip file of JSON files. Each finding is reported as an error or a warning depending on its
+// severity and the configured thresholds.
+//
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const vulnerabilitySeverityNone = "none"
+const vulnerabilitySeverityUnknown = "unknown"
+
+var advisoryDatabasePath string
+var vulnerabilityErrorSeverity = "high"
+var vulnerabilityWarningSeverity = vulnerabilitySeverityUnknown
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Severities in increasing order. A threshold of none disables the error or warning reporting.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var vulnerabilitySeverityRanks = map[string]int{
+	vulnerabilitySeverityUnknown: 0,
+	"low":                        1,
+	"medium":                     2,
+	"high":                       3,
+	"critical":                   4,
+	vulnerabilitySeverityNone:    5,
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// OSV ecosystems of the rpm based distributions, by the ID of /etc/os-release
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var rpmAdvisoryEcosystems = map[string]string{
+	"rhel":                "Red Hat",
+	"rocky":               "Rocky Linux",
+	"almalinux":           "AlmaLinux",
+	"mariner":             "Mariner",
+	"azurelinux":          "Azure Linux",
+	"opensuse-leap":       "openSUSE",
+	"opensuse-tumbleweed": "openSUSE",
+	"sles":                "SUSE",
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// These structures define the parts of an OSV advisory used by the scan
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type osvSeverityStruct struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvEventStruct struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+	Limit        string `json:"limit"`
+}
+
+type osvAffectedStruct struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Severity []osvSeverityStruct `json:"severity"`
+	Ranges   []struct {
+		Type   string           `json:"type"`
+		Events []osvEventStruct `json:"events"`
+	} `json:"ranges"`
+	Versions          []string `json:"versions"`
+	EcosystemSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"ecosystem_specific"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type osvAdvisoryStruct struct {
+	ID               string              `json:"id"`
+	Aliases          []string            `json:"aliases"`
+	Summary          string              `json:"summary"`
+	Withdrawn        string              `json:"withdrawn"`
+	Severity         []osvSeverityStruct `json:"severity"`
+	Affected         []osvAffectedStruct `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a vulnerability found in a package of the SBOM
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type vulnerabilityFindingStruct struct {
+	ID                string   `json:"ID"`
+	Aliases           []string `json:"Aliases,omitempty"`
+	Summary           string   `json:"Summary"`
+	Severity          string   `json:"Severity"`
+	Score             string   `json:"Score,omitempty"`
+	Ecosystem         string   `json:"Ecosystem"`
+	Package           string   `json:"Package"`
+	Version           string   `json:"Version"`
+	FixedVersion      string   `json:"FixedVersion,omitempty"`
+	InstalledPackages []string `json:"InstalledPackages"`
+	Status            string   `json:"Status"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines the vulnerability scan results included in the HTML report and the JSON output
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type vulnerabilityReportStruct struct {
+	AdvisoryDatabase   string                       `json:"AdvisoryDatabase"`
+	Advisories         int                          `json:"Advisories"`
+	Packages           int                          `json:"Packages"`
+	ErrorSeverity      string                       `json:"ErrorSeverity"`
+	WarningSeverity    string                       `json:"WarningSeverity"`
+	FindingsBySeverity map[string]int               `json:"FindingsBySeverity"`
+	Findings           []vulnerabilityFindingStruct `json:"Findings"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the OSV ecosystem of a package of the SBOM, or an empty string if the package can not be matched
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPackageEcosystem(sbomPackage sbomPackageStruct, operatingSystem string) string {
+	switch sbomPackage.Type {
+	case "apk":
+		return "Alpine"
+	case "deb":
+		if operatingSystem == "ubuntu" {
+			return "Ubuntu"
+		}
+		return "Debian"
+	case "rpm":
+		return rpmAdvisoryEcosystems[operatingSystem]
+	case "golang":
+		return "Go"
+	}
+	return ""
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the names an advisory may use for a package. Debian, Ubuntu and Alpine advisories use the source package name.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getPackageAdvisoryNames(sbomPackage sbomPackageStruct) []string {
+	names := []string{sbomPackage.Name}
+	if sbomPackage.Source != "" && sbomPackage.Source != sbomPackage.Name {
+		names = append(names, sbomPackage.Source)
+	}
+	return names
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether the release of an advisory ecosystem (for example Debian:12 or Alpine:v3.18) applies to the rootfs release
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func advisoryReleaseMatches(ecosystem string, operatingSystemVersion string) bool {
+	parts := strings.Split(ecosystem, ":")
+	if len(parts) == 1 || operatingSystemVersion == "" {
+		return true
+	}
+
+	for _, part := range parts[1:] {
+		release := strings.TrimPrefix(part, "v")
+		if release == "" || !unicode.IsDigit(rune(release[0])) {
+			continue
+		}
+		return operatingSystemVersion == release || strings.HasPrefix(operatingSystemVersion, release+".")
+	}
+
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Splits a version at the first non-digit (or digit) character
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func splitVersionPrefix(version string, digits bool) (string, string) {
+	index := 0
+	for index < len(version) && (version[index] >= '0' && version[index] <= '9') == digits {
+		index++
+	}
+	return version[:index], version[index:]
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 numeric strings of any length
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareNumericStrings(a string, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the dpkg sort weight of a version character: ~ sorts before everything, letters before non-letters
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getDebianVersionCharacterOrder(version string, index int) int {
+	if index >= len(version) {
+		return 0
+	}
+	character := version[index]
+	switch {
+	case character == '~':
+		return -1
+	case (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z'):
+		return int(character)
+	}
+	return int(character) + 256
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares the upstream version or revision parts of 2 Debian versions, as dpkg does
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareDebianVersionPart(a string, b string) int {
+	for a != "" || b != "" {
+		var aText, bText string
+		aText, a = splitVersionPrefix(a, false)
+		bText, b = splitVersionPrefix(b, false)
+		for index := 0; index < len(aText) || index < len(bText); index++ {
+			aOrder := getDebianVersionCharacterOrder(aText, index)
+			bOrder := getDebianVersionCharacterOrder(bText, index)
+			if aOrder != bOrder {
+				if aOrder < bOrder {
+					return -1
+				}
+				return 1
+			}
+		}
+
+		var aNumber, bNumber string
+		aNumber, a = splitVersionPrefix(a, true)
+		bNumber, b = splitVersionPrefix(b, true)
+		if result := compareNumericStrings(aNumber, bNumber); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 Debian versions ([epoch:]upstream[-revision])
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareDebianVersions(a string, b string) int {
+	split := func(version string) (string, string, string) {
+		epoch := "0"
+		if index := strings.Index(version, ":"); index >= 0 {
+			epoch, version = version[:index], version[index+1:]
+		}
+		revision := ""
+		if index := strings.LastIndex(version, "-"); index >= 0 {
+			version, revision = version[:index], version[index+1:]
+		}
+		return epoch, version, revision
+	}
+
+	aEpoch, aUpstream, aRevision := split(a)
+	bEpoch, bUpstream, bRevision := split(b)
+	if result := compareNumericStrings(aEpoch, bEpoch); result != 0 {
+		return result
+	}
+	if result := compareDebianVersionPart(aUpstream, bUpstream); result != 0 {
+		return result
+	}
+	return compareDebianVersionPart(aRevision, bRevision)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 Alpine versions. The pre-release suffixes sort before the release, which maps onto the ~ of Debian versions.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareAlpineVersions(a string, b string) int {
+	preRelease := strings.NewReplacer("_alpha", "~alpha", "_beta", "~beta", "_pre", "~pre", "_rc", "~rc")
+	return compareDebianVersions(preRelease.Replace(a), preRelease.Replace(b))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares the version or release parts of 2 rpm versions, as rpmvercmp does
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareRpmVersionPart(a string, b string) int {
+	isSeparator := func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character) && character != '~' && character != '^'
+	}
+
+	for a != "" || b != "" {
+		a = strings.TrimLeftFunc(a, isSeparator)
+		b = strings.TrimLeftFunc(b, isSeparator)
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case !strings.HasPrefix(a, "^"):
+				return 1
+			case !strings.HasPrefix(b, "^"):
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		var aSegment, bSegment string
+		digits := a[0] >= '0' && a[0] <= '9'
+		aSegment, a = splitRpmVersionSegment(a, digits)
+		bSegment, b = splitRpmVersionSegment(b, digits)
+		if bSegment == "" {
+			if digits {
+				return 1
+			}
+			return -1
+		}
+
+		if digits {
+			if result := compareNumericStrings(aSegment, bSegment); result != 0 {
+				return result
+			}
+		} else if result := strings.Compare(aSegment, bSegment); result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Splits an rpm version at the end of its leading run of digits or letters
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func splitRpmVersionSegment(version string, digits bool) (string, string) {
+	index := 0
+	for index < len(version) {
+		character := rune(version[index])
+		if (digits && !unicode.IsDigit(character)) || (!digits && !unicode.IsLetter(character)) {
+			break
+		}
+		index++
+	}
+	return version[:index], version[index:]
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 rpm versions ([epoch:]version[-release])
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareRpmVersions(a string, b string) int {
+	split := func(version string) (string, string, string) {
+		epoch := "0"
+		if index := strings.Index(version, ":"); index >= 0 {
+			epoch, version = version[:index], version[index+1:]
+		}
+		release := ""
+		if index := strings.LastIndex(version, "-"); index >= 0 {
+			version, release = version[:index], version[index+1:]
+		}
+		return epoch, version, release
+	}
+
+	aEpoch, aVersion, aRelease := split(a)
+	bEpoch, bVersion, bRelease := split(b)
+	if result := compareNumericStrings(aEpoch, bEpoch); result != 0 {
+		return result
+	}
+	if result := compareRpmVersionPart(aVersion, bVersion); result != 0 {
+		return result
+	}
+	if aRelease == "" || bRelease == "" {
+		return 0
+	}
+	return compareRpmVersionPart(aRelease, bRelease)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 semantic versions. The v prefix of Go modules and the go prefix of Go releases are ignored.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareSemanticVersions(a string, b string) int {
+	split := func(version string) ([]string, string) {
+		version = strings.TrimPrefix(strings.TrimPrefix(version, "go"), "v")
+		if index := strings.Index(version, "+"); index >= 0 {
+			version = version[:index]
+		}
+		preRelease := ""
+		if index := strings.Index(version, "-"); index >= 0 {
+			version, preRelease = version[:index], version[index+1:]
+		} else if index := strings.IndexFunc(version, unicode.IsLetter); index >= 0 {
+			version, preRelease = version[:index], version[index:]
+		}
+		return strings.Split(version, "."), preRelease
+	}
+
+	aCore, aPreRelease := split(a)
+	bCore, bPreRelease := split(b)
+	for index := 0; index < len(aCore) || index < len(bCore); index++ {
+		aNumber, bNumber := "0", "0"
+		if index < len(aCore) {
+			aNumber = aCore[index]
+		}
+		if index < len(bCore) {
+			bNumber = bCore[index]
+		}
+		if result := compareNumericStrings(aNumber, bNumber); result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case aPreRelease == bPreRelease:
+		return 0
+	case aPreRelease == "":
+		return 1
+	case bPreRelease == "":
+		return -1
+	}
+
+	aIdentifiers := strings.Split(aPreRelease, ".")
+	bIdentifiers := strings.Split(bPreRelease, ".")
+	for index := 0; index < len(aIdentifiers) && index < len(bIdentifiers); index++ {
+		_, aErr := strconv.ParseUint(aIdentifiers[index], 10, 64)
+		_, bErr := strconv.ParseUint(bIdentifiers[index], 10, 64)
+		var result int
+		switch {
+		case aErr == nil && bErr == nil:
+			result = compareNumericStrings(aIdentifiers[index], bIdentifiers[index])
+		case aErr == nil:
+			result = -1
+		case bErr == nil:
+			result = 1
+		default:
+			result = strings.Compare(aIdentifiers[index], bIdentifiers[index])
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return compareNumericStrings(strconv.Itoa(len(aIdentifiers)), strconv.Itoa(len(bIdentifiers)))
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Compares 2 versions of a package using the version ordering of its ecosystem
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func compareEcosystemVersions(ecosystem string, rangeType string, a string, b string) int {
+	if rangeType == "SEMVER" {
+		return compareSemanticVersions(a, b)
+	}
+
+	switch ecosystem {
+	case "Debian", "Ubuntu":
+		return compareDebianVersions(a, b)
+	case "Alpine":
+		return compareAlpineVersions(a, b)
+	case "Go":
+		return compareSemanticVersions(a, b)
+	}
+	return compareRpmVersions(a, b)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks whether a version is affected by an affected entry of an advisory. Returns the version which fixes it when it is known.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func isVersionAffected(affected osvAffectedStruct, ecosystem string, version string) (bool, string) {
+	for _, affectedVersion := range affected.Versions {
+		if affectedVersion == version || (ecosystem == "Go" && compareSemanticVersions(affectedVersion, version) == 0) {
+			return true, ""
+		}
+	}
+
+	for _, affectedRange := range affected.Ranges {
+		if affectedRange.Type != "SEMVER" && affectedRange.Type != "ECOSYSTEM" {
+			continue
+		}
+
+		eventVersion := func(event osvEventStruct) string {
+			return event.Introduced + event.Fixed + event.LastAffected + event.Limit
+		}
+		events := append([]osvEventStruct(nil), affectedRange.Events...)
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].Introduced == "0" || events[j].Introduced == "0" {
+				return events[i].Introduced == "0" && events[j].Introduced != "0"
+			}
+			return compareEcosystemVersions(ecosystem, affectedRange.Type, eventVersion(events[i]), eventVersion(events[j])) < 0
+		})
+
+		var vulnerable bool
+		var fixedVersion string
+		for _, event := range events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || compareEcosystemVersions(ecosystem, affectedRange.Type, version, event.Introduced) >= 0 {
+					vulnerable = true
+				}
+			case event.Fixed != "":
+				if compareEcosystemVersions(ecosystem, affectedRange.Type, version, event.Fixed) >= 0 {
+					vulnerable = false
+				} else if vulnerable && fixedVersion == "" {
+					fixedVersion = event.Fixed
+				}
+			case event.LastAffected != "":
+				if compareEcosystemVersions(ecosystem, affectedRange.Type, version, event.LastAffected) > 0 {
+					vulnerable = false
+				}
+			case event.Limit != "":
+				if event.Limit != "*" && compareEcosystemVersions(ecosystem, affectedRange.Type, version, event.Limit) >= 0 {
+					vulnerable = false
+				}
+			}
+		}
+		if vulnerable {
+			return true, fixedVersion
+		}
+	}
+
+	return false, ""
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the CVSS v3 base score of a CVSS v3 vector (for example CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H)
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getCVSSv3BaseScore(vector string) (float64, bool) {
+	var weights = map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	var metrics = map[string]float64{}
+	var scopeChanged bool
+
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, false
+	}
+	for _, metric := range strings.Split(vector, "/")[1:] {
+		keyValue := strings.SplitN(metric, ":", 2)
+		if len(keyValue) != 2 {
+			return 0, false
+		}
+		if keyValue[0] == "S" {
+			scopeChanged = keyValue[1] == "C"
+			continue
+		}
+		if metricWeights, found := weights[keyValue[0]]; found {
+			weight, found := metricWeights[keyValue[1]]
+			if !found {
+				return 0, false
+			}
+			metrics[keyValue[0]] = weight
+		}
+	}
+	if len(metrics) != len(weights) {
+		return 0, false
+	}
+
+	if scopeChanged && metrics["PR"] == 0.62 {
+		metrics["PR"] = 0.68
+	} else if scopeChanged && metrics["PR"] == 0.27 {
+		metrics["PR"] = 0.5
+	}
+
+	roundUp := func(score float64) float64 {
+		integer := math.Round(score * 100000)
+		if math.Mod(integer, 10000) == 0 {
+			return integer / 100000
+		}
+		return (math.Floor(integer/10000) + 1) / 10
+	}
+
+	impactSubScore := 1 - (1-metrics["C"])*(1-metrics["I"])*(1-metrics["A"])
+	impact := 6.42 * impactSubScore
+	if scopeChanged {
+		impact = 7.52*(impactSubScore-0.029) - 3.25*math.Pow(impactSubScore-0.02, 15)
+	}
+	exploitability := 8.22 * metrics["AV"] * metrics["AC"] * metrics["PR"] * metrics["UI"]
+
+	if impact <= 0 {
+		return 0, true
+	}
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the severity of a CVSS base score
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getCVSSSeverity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	}
+	return vulnerabilitySeverityUnknown
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Normalizes the severity names used by the advisory databases
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func normalizeVulnerabilitySeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical":
+		return "critical"
+	case "high", "important":
+		return "high"
+	case "medium", "moderate":
+		return "medium"
+	case "low", "negligible", "unimportant":
+		return "low"
+	}
+	return vulnerabilitySeverityUnknown
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Returns the severity and the CVSS score of an advisory for an affected package. The database severity is preferred to the CVSS score.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func getAdvisorySeverity(advisory *osvAdvisoryStruct, affected osvAffectedStruct) (string, string) {
+	var score string
+	var severity = vulnerabilitySeverityUnknown
+
+	for _, severityScore := range append(append([]osvSeverityStruct(nil), affected.Severity...), advisory.Severity...) {
+		switch severityScore.Type {
+		case "CVSS_V3":
+			if baseScore, ok := getCVSSv3BaseScore(severityScore.Score); ok && score == "" {
+				score = fmt.Sprintf("%.1f", baseScore)
+				severity = getCVSSSeverity(baseScore)
+			}
+		case "Ubuntu":
+			if severity == vulnerabilitySeverityUnknown {
+				severity = normalizeVulnerabilitySeverity(severityScore.Score)
+			}
+		}
+	}
+
+	for _, databaseSeverity := range []string{advisory.DatabaseSpecific.Severity, affected.DatabaseSpecific.Severity, affected.EcosystemSpecific.Severity} {
+		if normalized := normalizeVulnerabilitySeverity(databaseSeverity); normalized != vulnerabilitySeverityUnknown {
+			return normalized, score
+		}
+	}
+
+	return severity, score
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Decodes a JSON document of the advisory database, which holds either a single advisory or an array of advisories
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func decodeAdvisories(reader io.Reader) ([]osvAdvisoryStruct, error) {
+	var advisories []osvAdvisoryStruct
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("[")) {
+		err = json.Unmarshal(content, &advisories)
+		return advisories, err
+	}
+
+	var advisory osvAdvisoryStruct
+	if err := json.Unmarshal(content, &advisory); err != nil {
+		return nil, err
+	}
+	return []osvAdvisoryStruct{advisory}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Loads the advisories of the database which affect one of the given ecosystem/package names. Returns the advisories indexed by
+// ecosystem/package name and the number of advisories read.
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func loadAdvisoryDatabase(databasePath string, packageKeys map[string]bool) (map[string][]*osvAdvisoryStruct, int, error) {
+	var index = map[string][]*osvAdvisoryStruct{}
+	var count int
+
+	addAdvisories := func(reader io.Reader, name string) error {
+		advisories, err := decodeAdvisories(reader)
+		if err != nil {
+			return fmt.Errorf("unable to decode %s: %s", name, err.Error())
+		}
+		for advisoryIndex := range advisories {
+			advisory := &advisories[advisoryIndex]
+			count++
+			if advisory.Withdrawn != "" {
+				continue
+			}
+			var indexed = map[string]bool{}
+			for _, affected := range advisory.Affected {
+				key := strings.SplitN(affected.Package.Ecosystem, ":", 2)[0] + "/" + affected.Package.Name
+				if packageKeys[key] && !indexed[key] {
+					indexed[key] = true
+					index[key] = append(index[key], advisory)
+				}
+			}
+		}
+		return nil
+	}
+
+	info, err := os.Stat(databasePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case info.IsDir():
+		err = filepath.Walk(databasePath, func(filePath string, fileInfo os.FileInfo, err error) error {
+			if err != nil || fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".json") {
+				return err
+			}
+			file, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return addAdvisories(file, filePath)
+		})
+	case strings.HasSuffix(databasePath, ".zip"):
+		var archive *zip.ReadCloser
+		archive, err = zip.OpenReader(databasePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer archive.Close()
+		for _, archiveFile := range archive.File {
+			if archiveFile.FileInfo().IsDir() || !strings.HasSuffix(archiveFile.Name, ".json") {
+				continue
+			}
+			var file io.ReadCloser
+			if file, err = archiveFile.Open(); err != nil {
+				break
+			}
+			err = addAdvisories(file, archiveFile.Name)
+			file.Close()
+			if err != nil {
+				break
+			}
+		}
+	default:
+		var file *os.File
+		file, err = os.Open(databasePath)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer file.Close()
+		err = addAdvisories(file, databasePath)
+	}
+
+	return index, count, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Checks a vulnerability severity threshold given on the command line
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func validVulnerabilitySeverity(severity string) bool {
+	_, found := vulnerabilitySeverityRanks[severity]
+	return found
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Matches the packages of the SBOM against the advisory database and reports the findings by severity
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func scanPluginVulnerabilities(databasePath string) bool {
+	var report = vulnerabilityReportStruct{
+		AdvisoryDatabase:   databasePath,
+		Packages:           len(sbomPackages),
+		ErrorSeverity:      vulnerabilityErrorSeverity,
+		WarningSeverity:    vulnerabilityWarningSeverity,
+		FindingsBySeverity: map[string]int{},
+	}
+	var findings = map[string]*vulnerabilityFindingStruct{}
+	var packageKeys = map[string]bool{}
+
+	operatingSystem, operatingSystemVersion := getRootfsOperatingSystem()
+
+	for _, sbomPackage := range sbomPackages {
+		if ecosystem := getPackageEcosystem(sbomPackage, operatingSystem); ecosystem != "" {
+			for _, name := range getPackageAdvisoryNames(sbomPackage) {
+				packageKeys[ecosystem+"/"+name] = true
+			}
+		}
+	}
+
+	advisories, count, err := loadAdvisoryDatabase(databasePath, packageKeys)
+	if err != nil {
+		printError("Unable to load the advisory database " + databasePath + "! " + err.Error())
+		return false
+	}
+	report.Advisories = count
+
+	for _, sbomPackage := range sbomPackages {
+		ecosystem := getPackageEcosystem(sbomPackage, operatingSystem)
+		if ecosystem == "" || sbomPackage.Version == "" || sbomPackage.Version == "(devel)" {
+			continue
+		}
+
+		for _, name := range getPackageAdvisoryNames(sbomPackage) {
+			for _, advisory := range advisories[ecosystem+"/"+name] {
+				for _, affected := range advisory.Affected {
+					if strings.SplitN(affected.Package.Ecosystem, ":", 2)[0] != ecosystem || affected.Package.Name != name {
+						continue
+					}
+					if !advisoryReleaseMatches(affected.Package.Ecosystem, operatingSystemVersion) {
+						continue
+					}
+					vulnerable, fixedVersion := isVersionAffected(affected, ecosystem, sbomPackage.Version)
+					if !vulnerable {
+						continue
+					}
+
+					key := advisory.ID + "/" + ecosystem + "/" + name + "@" + sbomPackage.Version
+					finding, found := findings[key]
+					if !found {
+						severity, score := getAdvisorySeverity(advisory, affected)
+						finding = &vulnerabilityFindingStruct{
+							ID:           advisory.ID,
+							Aliases:      advisory.Aliases,
+							Summary:      advisory.Summary,
+							Severity:     severity,
+							Score:        score,
+							Ecosystem:    affected.Package.Ecosystem,
+							Package:      name,
+							Version:      sbomPackage.Version,
+							FixedVersion: fixedVersion,
+						}
+						findings[key] = finding
+					}
+					installedPackage := sbomPackage.Name + " (" + sbomPackage.Location + ")"
+					if !stringInSlice(installedPackage, finding.InstalledPackages) {
+						finding.InstalledPackages = append(finding.InstalledPackages, installedPackage)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	for _, finding := range findings {
+		report.Findings = append(report.Findings, *finding)
+	}
+	sort.Slice(report.Findings, func(i, j int) bool {
+		iRank, jRank := vulnerabilitySeverityRanks[report.Findings[i].Severity], vulnerabilitySeverityRanks[report.Findings[j].Severity]
+		if iRank != jRank {
+			return iRank > jRank
+		}
+		if report.Findings[i].Package != report.Findings[j].Package {
+			return report.Findings[i].Package < report.Findings[j].Package
+		}
+		return report.Findings[i].ID < report.Findings[j].ID
+	})
+
+	var errorFindings int
+	for index := range report.Findings {
+		finding := &report.Findings[index]
+		report.FindingsBySeverity[finding.Severity]++
+
+		severity := strings.ToUpper(finding.Severity)
+		if finding.Score != "" {
+			severity += " " + finding.Score
+		}
+		id := finding.ID
+		if len(finding.Aliases) > 0 {
+			id += " (" + strings.Join(finding.Aliases, ", ") + ")"
+		}
+		fixed := "no fixed version is known"
+		if finding.FixedVersion != "" {
+			fixed = "fixed in " + finding.FixedVersion
+		}
+		message := fmt.Sprintf("Vulnerability %s [%s] in %s %s, %s: %s Installed as %s.", id, severity, finding.Package, finding.Version, fixed,
+			strings.TrimSuffix(finding.Summary, "."), strings.Join(finding.InstalledPackages, ", "))
+
+		rank := vulnerabilitySeverityRanks[finding.Severity]
+		switch {
+		case rank >= vulnerabilitySeverityRanks[vulnerabilityErrorSeverity]:
+			finding.Status = "Error"
+			errorFindings++
+			printError(message)
+		case rank >= vulnerabilitySeverityRanks[vulnerabilityWarningSeverity]:
+			finding.Status = "Warning"
+			printWarning(message)
+		default:
+			finding.Status = "Ignored"
+			if inspectionData.verboseOutput {
+				printMessage("    " + message)
+			}
+		}
+	}
+	inspectionData.Vulnerabilities = &report
+
+	if len(report.Findings) == 0 {
+		printSuccess(fmt.Sprintf("Vulnerability scan: no known vulnerabilities were found in the %d packages of the SBOM (%d advisories in %s)",
+			len(sbomPackages), count, databasePath))
+		return true
+	}
+
+	var severities []string
+	for _, severity := range []string{"critical", "high", "medium", "low", vulnerabilitySeverityUnknown} {
+		if report.FindingsBySeverity[severity] > 0 {
+			severities = append(severities, fmt.Sprintf("%d %s", report.FindingsBySeverity[severity], severity))
+		}
+	}
+	printMessage(fmt.Sprintf("Vulnerability scan: %d vulnerabilities (%s) were found in the %d packages of the SBOM (%d advisories in %s)",
+		len(report.Findings), strings.Join(severities, ", "), len(sbomPackages), count, databasePath))
+
+	return errorFindings == 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// This structure defines a version comparison test case
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+type versionComparisonTestStruct struct {
+	a        string
+	b        string
+	expected int
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Runs the version comparison test cases against a compare function, in both directions
+//////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func testVersionComparisons(t *testing.T, compare func(string, string) int, testCases []versionComparisonTestStruct) {
+	for _, testCase := range testCases {
+		if result := compare(testCase.a, testCase.b); result != testCase.expected {
+			t.Errorf("compare(%q, %q) = %d, expected %d", testCase.a, testCase.b, result, testCase.expected)
+		}
+		if result := compare(testCase.b, testCase.a); result != -testCase.expected {
+			t.Errorf("compare(%q, %q) = %d, expected %d", testCase.b, testCase.a, result, -testCase.expected)
+		}
+	}
+}
+
+func TestCompareDebianVersions(t *testing.T) {
+	testVersionComparisons(t, compareDebianVersions, []versionComparisonTestStruct{
+		{"1:1.0", "2.0", 1},
+		{"0:1.0", "1.0", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~", "1.0~rc1", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0-1", "1.0-2", -1},
+		{"1.2.10", "1.2.9", 1},
+		{"2.31-13+deb11u5", "2.31-13+deb11u10", -1},
+		{"1.0", "1.0", 0},
+	})
+}
+
+func TestCompareAlpineVersions(t *testing.T) {
+	testVersionComparisons(t, compareAlpineVersions, []versionComparisonTestStruct{
+		{"1.2_rc1-r0", "1.2-r0", -1},
+		{"1.2_alpha1-r0", "1.2_beta1-r0", -1},
+		{"1.2-r0", "1.2-r1", -1},
+		{"1.2.10-r0", "1.2.9-r3", 1},
+		{"1.2-r0", "1.2-r0", 0},
+	})
+}
+
+func TestCompareRpmVersions(t *testing.T) {
+	testVersionComparisons(t, compareRpmVersions, []versionComparisonTestStruct{
+		{"1:1.0-1", "2.0-1", 1},
+		{"1.0~rc1-1", "1.0-1", -1},
+		{"1.0^git1-1", "1.0-1", 1},
+		{"1.10-1", "1.9-1", 1},
+		{"1.0-1.el8", "1.0-2.el8", -1},
+		{"1.0a", "1.0", 1},
+		{"1.0-1", "1.0", 0},
+		{"1.0-1", "1.0-1", 0},
+	})
+}
+
+func TestCompareSemanticVersions(t *testing.T) {
+	testVersionComparisons(t, compareSemanticVersions, []versionComparisonTestStruct{
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3+build.5", "1.2.3", 0},
+		{"go1.20", "go1.9", 1},
+		{"go1.21rc1", "go1.21", -1},
+		{"1.2", "1.2.0", 0},
+	})
+}
+
+func TestGetCVSSv3BaseScore(t *testing.T) {
+	var testCases = []struct {
+		vector   string
+		score    float64
+		expected bool
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0, true},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, true},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, true},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", 6.4, true},
+		{"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.6, true},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0, true},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 0, false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", 0, false},
+		{"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 0, false},
+	}
+
+	for _, testCase := range testCases {
+		score, ok := getCVSSv3BaseScore(testCase.vector)
+		if ok != testCase.expected || score != testCase.score {
+			t.Errorf("getCVSSv3BaseScore(%q) = %v, %v, expected %v, %v", testCase.vector, score, ok, testCase.score, testCase.expected)
+		}
+	}
+}